@@ -0,0 +1,346 @@
+// Package binding fills structs from HTTP requests, reading path variables,
+// query parameters, headers and the request body as described by struct tags,
+// so that decoders do not have to look up and convert parameters by hand.
+//
+// Fields are bound using the following tags.
+//
+//   - `path:"id"` binds the path variable id, e.g. defined by the path
+//     /users/{id} of a gorilla/mux route.
+//   - `query:"limit"` binds the query parameter limit.
+//   - `header:"X-Tenant"` binds the header X-Tenant.
+//   - `body:""` binds the decoded request body. In case no field has this tag,
+//     the request body is decoded into the struct itself.
+//
+// Parameters can be bound to fields of type string, bool, numbers,
+// time.Duration, time.Time in RFC 3339 format and types implementing
+// encoding.TextUnmarshaler, or pointers or slices of these. Slices receive all
+// values of repeated parameters. Parameters are further described by the
+// following tags.
+//
+//   - `required:"true"` rejects requests not having the parameter.
+//   - `default:"10"` is used in case the request does not have the parameter.
+//   - `enum:"asc,desc"` rejects values other than the given ones.
+//
+// All invalid parameters of a request are reported at once by an
+// InvalidInputError.
+package binding
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// LocationBody is the location of errors decoding the request body.
+	LocationBody = "body"
+	// LocationHeader is the location of parameters bound from headers.
+	LocationHeader = "header"
+	// LocationPath is the location of parameters bound from path variables.
+	LocationPath = "path"
+	// LocationQuery is the location of parameters bound from the query string.
+	LocationQuery = "query"
+)
+
+// locations are the locations of parameters in the order they are bound.
+var locations = []string{
+	LocationPath,
+	LocationQuery,
+	LocationHeader,
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// Bind decodes the JSON body of the given request and binds its parameters to
+// the struct the given pointer points to, see BindParams. Empty bodies are
+// ignored.
+func Bind(r *http.Request, v interface{}) error {
+	return BindWith(r, v, func(r io.Reader, v interface{}) error {
+		return json.NewDecoder(r).Decode(v)
+	})
+}
+
+// BindWith is like Bind but decodes the request body using the given decode
+// function.
+func BindWith(r *http.Request, v interface{}, decode func(r io.Reader, v interface{}) error) error {
+	s, err := structValue(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var params []ParamError
+	if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		target := s
+		if f, ok := bodyField(s); ok {
+			target = f
+		}
+
+		err := decode(r.Body, target.Addr().Interface())
+		var maxBytesError *http.MaxBytesError
+		if errors.Is(err, io.EOF) {
+			// The body is empty, which is fine for requests only consisting of
+			// parameters.
+		} else if errors.As(err, &maxBytesError) {
+			// Bodies exceeding their maximum size are not invalid input, but
+			// are left to the server to respond with HTTP status 413.
+			return microerror.Mask(err)
+		} else if err != nil {
+			// Invalid bodies are reported together with the invalid
+			// parameters, so that clients can fix all of them at once.
+			params = append(params, ParamError{Location: LocationBody, Message: err.Error()})
+		}
+	}
+
+	params = append(params, bindParams(r, s)...)
+	if len(params) > 0 {
+		return microerror.Mask(InvalidInputError{params: params})
+	}
+
+	return nil
+}
+
+// BindParams binds the path variables, query parameters and headers of the
+// given request to the struct the given pointer points to. It returns an
+// InvalidInputError listing every parameter which is missing or invalid.
+func BindParams(r *http.Request, v interface{}) error {
+	s, err := structValue(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	params := bindParams(r, s)
+	if len(params) > 0 {
+		return microerror.Mask(InvalidInputError{params: params})
+	}
+
+	return nil
+}
+
+// bindParams binds the path variables, query parameters and headers of the
+// given request to the given struct and returns every parameter which is
+// missing or invalid.
+func bindParams(r *http.Request, s reflect.Value) []ParamError {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	lookups := map[string]func(key string) []string{
+		LocationHeader: func(key string) []string {
+			return r.Header.Values(key)
+		},
+		LocationPath: func(key string) []string {
+			value, ok := vars[key]
+			if !ok {
+				return nil
+			}
+			return []string{value}
+		},
+		LocationQuery: func(key string) []string {
+			return query[key]
+		},
+	}
+
+	var params []ParamError
+	for _, location := range locations {
+		params = append(params, bindFields(s, location, lookups[location])...)
+	}
+
+	return params
+}
+
+// bindFields binds the fields of the given struct having the tag of the given
+// location to the values returned by lookup. Fields of embedded structs are
+// bound as well.
+func bindFields(s reflect.Value, location string, lookup func(key string) []string) []ParamError {
+	var params []ParamError
+
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		// Exported fields of embedded structs are bound, even if the embedded
+		// struct itself is unexported.
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			params = append(params, bindFields(s.Field(i), location, lookup)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Tag.Get(location)
+		if name == "" || name == "-" {
+			continue
+		}
+
+		values := lookup(name)
+		if len(values) == 0 {
+			if d, ok := f.Tag.Lookup("default"); ok {
+				values = []string{d}
+			}
+		}
+		if len(values) == 0 {
+			if f.Tag.Get("required") == "true" {
+				params = append(params, ParamError{Location: location, Message: "is required", Name: name})
+			}
+			continue
+		}
+
+		if enum, ok := f.Tag.Lookup("enum"); ok {
+			message := checkEnum(values, strings.Split(enum, ","))
+			if message != "" {
+				params = append(params, ParamError{Location: location, Message: message, Name: name})
+				continue
+			}
+		}
+
+		err := setField(s.Field(i), values)
+		if err != nil {
+			params = append(params, ParamError{Location: location, Message: err.Error(), Name: name})
+		}
+	}
+
+	return params
+}
+
+// bodyField returns the field of the given struct having the body tag, if
+// any.
+func bodyField(s reflect.Value) (reflect.Value, bool) {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("body"); ok && t.Field(i).IsExported() {
+			return s.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func checkEnum(values []string, enum []string) string {
+	for _, v := range values {
+		var found bool
+		for _, e := range enum {
+			if v == strings.TrimSpace(e) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return "must be one of " + strings.Join(enum, ", ")
+		}
+	}
+
+	return ""
+}
+
+// setField sets the given field to the given values. Fields other than slices
+// receive the first value.
+func setField(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			err := setValue(s.Index(i), value)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+
+		return nil
+	}
+
+	return setValue(v, values[0])
+}
+
+func setValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		err := setValue(p.Elem(), value)
+		if err != nil {
+			return err
+		}
+		v.Set(p)
+
+		return nil
+	}
+
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("must be a time in RFC 3339 format")
+		}
+		v.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("must be a duration like 1m30s")
+		}
+		v.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a non-negative integer")
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		v.SetFloat(f)
+	default:
+		return errors.New("type " + v.Type().String() + " is not supported")
+	}
+
+	return nil
+}
+
+// structValue returns the struct the given pointer points to.
+func structValue(v interface{}) (reflect.Value, error) {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Pointer || p.IsNil() || p.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, microerror.Maskf(invalidTargetError, "target must be a non-nil pointer to a struct, got %T", v)
+	}
+
+	return p.Elem(), nil
+}
@@ -0,0 +1,145 @@
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type testRequest struct {
+	testEmbedded
+
+	ID      string        `path:"id" required:"true"`
+	Limit   int           `query:"limit" default:"10"`
+	Name    string        `json:"name"`
+	Order   string        `query:"order" enum:"asc,desc"`
+	Since   *time.Time    `query:"since"`
+	Tags    []string      `query:"tag"`
+	Timeout time.Duration `header:"X-Timeout"`
+}
+
+type testEmbedded struct {
+	Tenant string `header:"X-Tenant"`
+}
+
+func Test_Bind(t *testing.T) {
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		Body            string
+		Header          http.Header
+		URL             string
+		Vars            map[string]string
+		ExpectedParams  []ParamError
+		ExpectedRequest testRequest
+	}{
+		// Case 1 ensures path variables, query parameters, headers and the
+		// request body are bound.
+		{
+			Body:   `{"name":"test-name"}`,
+			Header: http.Header{"X-Tenant": {"test-tenant"}, "X-Timeout": {"5s"}},
+			URL:    "/users/test-id?limit=5&order=asc&since=2026-01-02T03:04:05Z&tag=a&tag=b",
+			Vars:   map[string]string{"id": "test-id"},
+			ExpectedRequest: testRequest{
+				testEmbedded: testEmbedded{Tenant: "test-tenant"},
+				ID:           "test-id",
+				Limit:        5,
+				Name:         "test-name",
+				Order:        "asc",
+				Since:        &since,
+				Tags:         []string{"a", "b"},
+				Timeout:      5 * time.Second,
+			},
+		},
+		// Case 2 ensures defaults are used and empty bodies are ignored.
+		{
+			Body:   "",
+			Header: http.Header{},
+			URL:    "/users/test-id",
+			Vars:   map[string]string{"id": "test-id"},
+			ExpectedRequest: testRequest{
+				ID:    "test-id",
+				Limit: 10,
+			},
+		},
+		// Case 3 ensures every invalid parameter is reported with its location.
+		{
+			Body:   "",
+			Header: http.Header{"X-Timeout": {"soon"}},
+			URL:    "/users?limit=many&order=random&since=yesterday",
+			Vars:   map[string]string{},
+			ExpectedParams: []ParamError{
+				{Location: LocationPath, Message: "is required", Name: "id"},
+				{Location: LocationQuery, Message: "must be an integer", Name: "limit"},
+				{Location: LocationQuery, Message: "must be one of asc, desc", Name: "order"},
+				{Location: LocationQuery, Message: "must be a time in RFC 3339 format", Name: "since"},
+				{Location: LocationHeader, Message: "must be a duration like 1m30s", Name: "X-Timeout"},
+			},
+		},
+		// Case 4 ensures invalid request bodies are reported.
+		{
+			Body:   `{"name":`,
+			Header: http.Header{},
+			URL:    "/users/test-id",
+			Vars:   map[string]string{"id": "test-id"},
+			ExpectedParams: []ParamError{
+				{Location: LocationBody, Message: "unexpected EOF"},
+			},
+		},
+		// Case 5 ensures invalid request bodies are reported together with
+		// invalid parameters.
+		{
+			Body:   `{"name":`,
+			Header: http.Header{},
+			URL:    "/users?limit=many",
+			Vars:   map[string]string{},
+			ExpectedParams: []ParamError{
+				{Location: LocationBody, Message: "unexpected EOF"},
+				{Location: LocationPath, Message: "is required", Name: "id"},
+				{Location: LocationQuery, Message: "must be an integer", Name: "limit"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		r := httptest.NewRequest(http.MethodPost, tc.URL, strings.NewReader(tc.Body))
+		r.Header = tc.Header
+		r = mux.SetURLVars(r, tc.Vars)
+
+		var request testRequest
+		err := Bind(r, &request)
+
+		if tc.ExpectedParams != nil {
+			if !IsInvalidInputError(err) {
+				t.Fatal("case", i+1, "expected", true, "got", false)
+			}
+			params := ToInvalidInputError(err).Params()
+			if !reflect.DeepEqual(params, tc.ExpectedParams) {
+				t.Fatal("case", i+1, "expected", tc.ExpectedParams, "got", params)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		if !reflect.DeepEqual(request, tc.ExpectedRequest) {
+			t.Fatal("case", i+1, "expected", tc.ExpectedRequest, "got", request)
+		}
+	}
+}
+
+func Test_Bind_InvalidTarget(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var request testRequest
+	err := Bind(r, request)
+	if !IsInvalidTarget(err) {
+		t.Fatal("expected", true, "got", false)
+	}
+}
@@ -0,0 +1,77 @@
+package binding
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+var invalidTargetError = &microerror.Error{
+	Kind: "invalidTargetError",
+}
+
+// IsInvalidTarget asserts invalidTargetError.
+func IsInvalidTarget(err error) bool {
+	return microerror.Cause(err) == invalidTargetError
+}
+
+// ParamError describes a single request parameter which could not be bound.
+type ParamError struct {
+	// Location is where the parameter was read from, one of LocationBody,
+	// LocationHeader, LocationPath and LocationQuery.
+	Location string
+	// Message describes why the parameter is invalid.
+	Message string
+	// Name is the name of the parameter, e.g. the query parameter name. It is
+	// empty for the request body.
+	Name string
+}
+
+// Error returns the description of the ParamError to implement the error
+// interface.
+func (e ParamError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s: %s", e.Location, e.Message)
+	}
+
+	return fmt.Sprintf("%s %#q: %s", e.Location, e.Name, e.Message)
+}
+
+// InvalidInputError indicates a request could not be bound because some of its
+// parameters are missing or invalid. The server responds with it using HTTP
+// status 400 and server.CodeInvalidInput.
+type InvalidInputError struct {
+	params []ParamError
+}
+
+// Error returns the message of the InvalidInputError listing every invalid
+// parameter to implement the error interface.
+func (e InvalidInputError) Error() string {
+	var descriptions []string
+	for _, p := range e.params {
+		descriptions = append(descriptions, p.Error())
+	}
+
+	return "invalid input: " + strings.Join(descriptions, ", ")
+}
+
+// Params returns the invalid parameters in the order of the fields of the
+// bound struct.
+func (e InvalidInputError) Params() []ParamError {
+	return e.params
+}
+
+// IsInvalidInputError asserts InvalidInputError.
+func IsInvalidInputError(err error) bool {
+	_, ok := microerror.Cause(err).(InvalidInputError)
+	return ok
+}
+
+// ToInvalidInputError asserts the given error to InvalidInputError and returns
+// it. ToInvalidInputError panics in case the underlying error is not of type
+// InvalidInputError. Therefore IsInvalidInputError should always be used to
+// verify the safe execution of ToInvalidInputError beforehand.
+func ToInvalidInputError(err error) InvalidInputError {
+	return microerror.Cause(err).(InvalidInputError)
+}
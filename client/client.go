@@ -0,0 +1,153 @@
+// Package client provides a client implementation to send requests to other
+// microservices, sharing TLS configuration, request ID and trace propagation
+// and instrumentation with the server package.
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/microkit/tls"
+)
+
+// Config represents the configuration used to create a new client.
+type Config struct {
+	// Logger is the logger used to print log messages.
+	Logger micrologger.Logger
+	// Registry is an optional prometheus registry the metrics of the client
+	// are registered with in addition to the default registry, e.g. the
+	// registry given via server.Config.Registry.
+	Registry *prometheus.Registry
+	// Transport is an optional HTTP transport used to send requests. It
+	// defaults to a clone of http.DefaultTransport using the TLS configuration
+	// of TLSCertFiles. Transport and TLSCertFiles must not be used together.
+	Transport http.RoundTripper
+
+	// MaxRetries is the maximum number of retries of idempotent requests. It
+	// defaults to 3. Negative values disable retries.
+	MaxRetries int
+	// RetryBackoff is the initial backoff between retries, which doubles with
+	// every retry. It defaults to 100 milliseconds.
+	RetryBackoff time.Duration
+	// RetryMaxBackoff is the maximum backoff between retries. It defaults to 5
+	// seconds.
+	RetryMaxBackoff time.Duration
+	// Timeout is the time a single attempt of a request waits for the response
+	// headers, after which it fails with an error matched by IsTimeout and is
+	// retried, if possible. It defaults to 60 seconds. Reading the response
+	// body is not limited, so that Server-Sent Events and large downloads are
+	// not cut off. Deadlines of whole requests including retries are set via
+	// the request context.
+	Timeout time.Duration
+	// TLSCertFiles are the client certificate and root CA files used to
+	// connect to HTTPS targets, if any.
+	TLSCertFiles tls.CertFiles
+}
+
+// New creates a new configured client object.
+func New(config Config) (Client, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "logger must not be empty")
+	}
+	if config.Transport != nil && !config.TLSCertFiles.IsEmpty() {
+		return nil, microerror.Maskf(invalidConfigError, "transport and TLS cert files must not be used together")
+	}
+
+	if config.Registry != nil {
+		err := RegisterMetrics(config.Registry)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = 100 * time.Millisecond
+	}
+	if config.RetryMaxBackoff == 0 {
+		config.RetryMaxBackoff = 5 * time.Second
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 60 * time.Second
+	}
+
+	transport := config.Transport
+	if transport == nil {
+		if config.TLSCertFiles.Logger == nil {
+			config.TLSCertFiles.Logger = config.Logger
+		}
+
+		tlsConfig, err := tls.LoadTLSConfig(config.TLSCertFiles)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		if tlsConfig != nil {
+			t.TLSClientConfig = tlsConfig
+		}
+		transport = t
+	}
+
+	// The transports are chained so that the request IDs and trace headers are
+	// propagated once and every single attempt is instrumented and limited by
+	// the timeout.
+	transport = &timeoutTransport{
+		next: transport,
+
+		timeout: config.Timeout,
+	}
+	transport = &instrumentationTransport{
+		next: transport,
+	}
+	transport = &retryTransport{
+		logger: config.Logger,
+		next:   transport,
+
+		backoff:    config.RetryBackoff,
+		maxBackoff: config.RetryMaxBackoff,
+		maxRetries: config.MaxRetries,
+	}
+	transport = &propagationTransport{
+		next: transport,
+	}
+
+	newClient := &client{
+		httpClient: &http.Client{
+			Transport: transport,
+		},
+	}
+
+	return newClient, nil
+}
+
+type client struct {
+	httpClient *http.Client
+}
+
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+		return nil, microerror.Mask(ParseResponseError(res))
+	}
+
+	return res, nil
+}
+
+func (c *client) HTTPClient() *http.Client {
+	return c.httpClient
+}
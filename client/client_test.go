@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/giantswarm/microkit/server"
+)
+
+// Test_Client_Retry ensures idempotent requests are retried while the target
+// is unavailable and non idempotent requests are not.
+func Test_Client_Retry(t *testing.T) {
+	testCases := []struct {
+		Method        string
+		Failures      int
+		ExpectedCalls int
+		ExpectedCode  int
+	}{
+		// Case 1 ensures a GET request is retried until it succeeds.
+		{
+			Method:        http.MethodGet,
+			Failures:      2,
+			ExpectedCalls: 3,
+			ExpectedCode:  http.StatusOK,
+		},
+		// Case 2 ensures a GET request is retried at most MaxRetries times.
+		{
+			Method:        http.MethodGet,
+			Failures:      5,
+			ExpectedCalls: 4,
+			ExpectedCode:  http.StatusServiceUnavailable,
+		},
+		// Case 3 ensures a POST request is not retried.
+		{
+			Method:        http.MethodPost,
+			Failures:      1,
+			ExpectedCalls: 1,
+			ExpectedCode:  http.StatusServiceUnavailable,
+		},
+	}
+
+	for i, tc := range testCases {
+		var calls int
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= tc.Failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		c, err := New(Config{
+			Logger:          microloggertest.New(),
+			RetryBackoff:    time.Millisecond,
+			RetryMaxBackoff: time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		req, err := http.NewRequest(tc.Method, s.URL, strings.NewReader("body"))
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		res, err := c.HTTPClient().Do(req)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != tc.ExpectedCode {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCode, "got", res.StatusCode)
+		}
+		if calls != tc.ExpectedCalls {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCalls, "got", calls)
+		}
+
+		s.Close()
+	}
+}
+
+// Test_Client_Propagation ensures request IDs and trace headers of the request
+// context are sent to the target.
+func Test_Client_Propagation(t *testing.T) {
+	var header http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer s.Close()
+
+	c, err := New(Config{Logger: microloggertest.New()})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	ctx := server.NewContextWithRequestID(context.Background(), "test-request-id")
+	ctx = server.NewContextWithTraceHeaders(ctx, http.Header{"Traceparent": []string{"test-trace"}})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	res.Body.Close()
+
+	if header.Get(server.RequestIDHeader) != "test-request-id" {
+		t.Fatal("expected", "test-request-id", "got", header.Get(server.RequestIDHeader))
+	}
+	if header.Get("traceparent") != "test-trace" {
+		t.Fatal("expected", "test-trace", "got", header.Get("traceparent"))
+	}
+}
+
+// Test_Client_ResponseError ensures microkit error response bodies are
+// decoded.
+func Test_Client_ResponseError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"RESOURCE_NOT_FOUND","error":"user not found","from":"test-service"}`))
+	}))
+	defer s.Close()
+
+	c, err := New(Config{Logger: microloggertest.New()})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	_, err = c.Do(req)
+	if !IsResponseError(err) {
+		t.Fatal("expected", true, "got", false)
+	}
+
+	responseError := ToResponseError(err)
+	if responseError.Code() != server.CodeResourceNotFound {
+		t.Fatal("expected", server.CodeResourceNotFound, "got", responseError.Code())
+	}
+	if responseError.Message() != "user not found" {
+		t.Fatal("expected", "user not found", "got", responseError.Message())
+	}
+	if responseError.From() != "test-service" {
+		t.Fatal("expected", "test-service", "got", responseError.From())
+	}
+	if responseError.StatusCode() != http.StatusNotFound {
+		t.Fatal("expected", http.StatusNotFound, "got", responseError.StatusCode())
+	}
+}
+
+// Test_Client_Registry ensures the metrics of the client are registered with
+// the configured registry.
+func Test_Client_Registry(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	registry := prometheus.NewRegistry()
+
+	// Creating multiple clients using the same registry must not fail.
+	for i := 0; i < 2; i++ {
+		_, err := New(Config{Logger: microloggertest.New(), Registry: registry})
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	c, err := New(Config{Logger: microloggertest.New(), Registry: registry})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	res.Body.Close()
+
+	n, err := testutil.GatherAndCount(registry, "client_request_total")
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if n == 0 {
+		t.Fatal("expected", "client_request_total", "got", n)
+	}
+}
+
+// Test_Client_Timeout ensures the timeout only limits the time until the
+// response headers are received, so that streamed response bodies are not cut
+// off.
+func Test_Client_Timeout(t *testing.T) {
+	testCases := []struct {
+		HeaderDelay  time.Duration
+		BodyDelay    time.Duration
+		ErrorMatcher func(err error) bool
+	}{
+		// Case 1 ensures response bodies are read beyond the timeout.
+		{
+			HeaderDelay:  0,
+			BodyDelay:    100 * time.Millisecond,
+			ErrorMatcher: nil,
+		},
+		// Case 2 ensures responses not sending their headers within the timeout
+		// fail.
+		{
+			HeaderDelay:  100 * time.Millisecond,
+			BodyDelay:    0,
+			ErrorMatcher: IsTimeout,
+		},
+	}
+
+	for i, tc := range testCases {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(tc.HeaderDelay)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("test-"))
+			_ = http.NewResponseController(w).Flush()
+			time.Sleep(tc.BodyDelay)
+			_, _ = w.Write([]byte("body"))
+		}))
+
+		c, err := New(Config{
+			Logger:     microloggertest.New(),
+			MaxRetries: -1,
+			Timeout:    50 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		res, err := c.Do(req)
+		if tc.ErrorMatcher != nil {
+			if !tc.ErrorMatcher(err) {
+				t.Fatal("case", i+1, "expected", true, "got", false)
+			}
+			s.Close()
+			continue
+		}
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		res.Body.Close()
+
+		if string(b) != "test-body" {
+			t.Fatal("case", i+1, "expected", "test-body", "got", string(b))
+		}
+
+		s.Close()
+	}
+}
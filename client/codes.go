@@ -0,0 +1,144 @@
+package client
+
+import (
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/microkit/server"
+)
+
+// HasCode expresses whether the given error is a ResponseError having the
+// given microkit error code.
+func HasCode(err error, code string) bool {
+	responseError, ok := microerror.Cause(err).(ResponseError)
+	return ok && responseError.Code() == code
+}
+
+// IsAccountExpired asserts a ResponseError having the code
+// server.CodeAccountExpired.
+func IsAccountExpired(err error) bool {
+	return HasCode(err, server.CodeAccountExpired)
+}
+
+// IsFailure asserts a ResponseError having the code server.CodeFailure.
+func IsFailure(err error) bool {
+	return HasCode(err, server.CodeFailure)
+}
+
+// IsImmutableAttribute asserts a ResponseError having the code
+// server.CodeImmutableAttribute.
+func IsImmutableAttribute(err error) bool {
+	return HasCode(err, server.CodeImmutableAttribute)
+}
+
+// IsInternalError asserts a ResponseError having the code
+// server.CodeInternalError.
+func IsInternalError(err error) bool {
+	return HasCode(err, server.CodeInternalError)
+}
+
+// IsInvalidCredentials asserts a ResponseError having the code
+// server.CodeInvalidCredentials.
+func IsInvalidCredentials(err error) bool {
+	return HasCode(err, server.CodeInvalidCredentials)
+}
+
+// IsInvalidInput asserts a ResponseError having the code
+// server.CodeInvalidInput.
+func IsInvalidInput(err error) bool {
+	return HasCode(err, server.CodeInvalidInput)
+}
+
+// IsNotAcceptable asserts a ResponseError having the code
+// server.CodeNotAcceptable.
+func IsNotAcceptable(err error) bool {
+	return HasCode(err, server.CodeNotAcceptable)
+}
+
+// IsNotSupported asserts a ResponseError having the code
+// server.CodeNotSupported.
+func IsNotSupported(err error) bool {
+	return HasCode(err, server.CodeNotSupported)
+}
+
+// IsNotYetAvailable asserts a ResponseError having the code
+// server.CodeNotYetAvailable.
+func IsNotYetAvailable(err error) bool {
+	return HasCode(err, server.CodeNotYetAvailable)
+}
+
+// IsPermissionDenied asserts a ResponseError having the code
+// server.CodePermissionDenied.
+func IsPermissionDenied(err error) bool {
+	return HasCode(err, server.CodePermissionDenied)
+}
+
+// IsPreconditionFailed asserts a ResponseError having the code
+// server.CodePreconditionFailed.
+func IsPreconditionFailed(err error) bool {
+	return HasCode(err, server.CodePreconditionFailed)
+}
+
+// IsRequestBodyTooLarge asserts a ResponseError having the code
+// server.CodeRequestBodyTooLarge.
+func IsRequestBodyTooLarge(err error) bool {
+	return HasCode(err, server.CodeRequestBodyTooLarge)
+}
+
+// IsResourceAlreadyExists asserts a ResponseError having the code
+// server.CodeResourceAlreadyExists.
+func IsResourceAlreadyExists(err error) bool {
+	return HasCode(err, server.CodeResourceAlreadyExists)
+}
+
+// IsResourceCreated asserts a ResponseError having the code
+// server.CodeResourceCreated.
+func IsResourceCreated(err error) bool {
+	return HasCode(err, server.CodeResourceCreated)
+}
+
+// IsResourceDeleted asserts a ResponseError having the code
+// server.CodeResourceDeleted.
+func IsResourceDeleted(err error) bool {
+	return HasCode(err, server.CodeResourceDeleted)
+}
+
+// IsResourceDeletionStarted asserts a ResponseError having the code
+// server.CodeResourceDeletionStarted.
+func IsResourceDeletionStarted(err error) bool {
+	return HasCode(err, server.CodeResourceDeletionStarted)
+}
+
+// IsResourceNotFound asserts a ResponseError having the code
+// server.CodeResourceNotFound.
+func IsResourceNotFound(err error) bool {
+	return HasCode(err, server.CodeResourceNotFound)
+}
+
+// IsResourceUpdated asserts a ResponseError having the code
+// server.CodeResourceUpdated.
+func IsResourceUpdated(err error) bool {
+	return HasCode(err, server.CodeResourceUpdated)
+}
+
+// IsSuccess asserts a ResponseError having the code server.CodeSuccess.
+func IsSuccess(err error) bool {
+	return HasCode(err, server.CodeSuccess)
+}
+
+// IsTooManyRequests asserts a ResponseError having the code
+// server.CodeTooManyRequests.
+func IsTooManyRequests(err error) bool {
+	return HasCode(err, server.CodeTooManyRequests)
+}
+
+// IsUnknownAttribute asserts a ResponseError having the code
+// server.CodeUnknownAttribute.
+func IsUnknownAttribute(err error) bool {
+	return HasCode(err, server.CodeUnknownAttribute)
+}
+
+// IsUnsupportedMediaType asserts a ResponseError having the code
+// server.CodeUnsupportedMediaType.
+func IsUnsupportedMediaType(err error) bool {
+	return HasCode(err, server.CodeUnsupportedMediaType)
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var timeoutError = &microerror.Error{
+	Kind: "timeoutError",
+}
+
+// IsTimeout asserts timeoutError, which indicates a single attempt of a
+// request did not receive response headers within Config.Timeout.
+func IsTimeout(err error) bool {
+	return microerror.Cause(err) == timeoutError
+}
+
+// ResponseError indicates a request was answered with an error response. It
+// carries the information of the microkit error response body.
+type ResponseError struct {
+	code       string
+	from       string
+	message    string
+	statusCode int
+}
+
+// Code returns the microkit error code of the error response, e.g.
+// RESOURCE_NOT_FOUND.
+func (e ResponseError) Code() string {
+	return e.code
+}
+
+// Error returns the message of the ResponseError to implement the error
+// interface.
+func (e ResponseError) Error() string {
+	return fmt.Sprintf("%s (%d %s from %s)", e.message, e.statusCode, e.code, e.from)
+}
+
+// From returns the name of the service which created the error response.
+func (e ResponseError) From() string {
+	return e.from
+}
+
+// Message returns the error message of the error response.
+func (e ResponseError) Message() string {
+	return e.message
+}
+
+// StatusCode returns the HTTP status code of the error response.
+func (e ResponseError) StatusCode() int {
+	return e.statusCode
+}
+
+// IsResponseError asserts ResponseError.
+func IsResponseError(err error) bool {
+	_, ok := microerror.Cause(err).(ResponseError)
+	return ok
+}
+
+// ToResponseError asserts the given error to ResponseError and returns it.
+// ToResponseError panics in case the underlying error is not of type
+// ResponseError. Therefore IsResponseError should always be used to verify the
+// safe execution of ToResponseError beforehand.
+func ToResponseError(err error) ResponseError {
+	return microerror.Cause(err).(ResponseError)
+}
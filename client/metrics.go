@@ -0,0 +1,56 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "client_request_total",
+			Help: "Number of times we have sent a HTTP request to a target.",
+		},
+		[]string{"code", "method", "target"},
+	)
+	requestTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "client_request_milliseconds",
+			Help: "Time taken to send a HTTP request to a target and receive its response headers, in milliseconds.",
+		},
+		[]string{"code", "method", "target"},
+	)
+	retryTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "client_retry_total",
+			Help: "Number of times we have retried a HTTP request to a target.",
+		},
+		[]string{"method", "target"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requestTotal)
+	prometheus.MustRegister(requestTime)
+	prometheus.MustRegister(retryTotal)
+}
+
+// RegisterMetrics registers the metrics of sent requests with the given
+// registerer, in addition to the default registerer they are always registered
+// with. That way they are served next to the metrics of a server configuring
+// its own registry. Registering them multiple times with the same registerer
+// has no effect.
+func RegisterMetrics(r prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{requestTotal, requestTime, retryTotal} {
+		err := r.Register(c)
+		if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
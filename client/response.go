@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// maxErrorBodySize is the maximum number of bytes read from error response
+	// bodies.
+	maxErrorBodySize = 1 << 20
+)
+
+// ParseResponseError turns the given response into a ResponseError in case
+// its status code is 400 or above. Otherwise nil is returned. The microkit
+// error response body, as written by the server's error encoder, is decoded
+// into the error's code, message and originating service. Responses not having
+// a microkit error response body are represented by their body or status text.
+// The response body is read, but not closed.
+func ParseResponseError(res *http.Response) error {
+	if res.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var body struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+		From  string `json:"from"`
+	}
+	err = json.Unmarshal(b, &body)
+	if err != nil || body.Code == "" {
+		body.Code = ""
+		body.Error = strings.TrimSpace(string(b))
+		body.From = ""
+		if res.Request != nil && res.Request.URL != nil {
+			body.From = res.Request.URL.Host
+		}
+	}
+	if body.Error == "" {
+		body.Error = http.StatusText(res.StatusCode)
+	}
+
+	responseError := ResponseError{
+		code:       body.Code,
+		from:       body.From,
+		message:    body.Error,
+		statusCode: res.StatusCode,
+	}
+
+	return microerror.Mask(responseError)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_ParseResponseError(t *testing.T) {
+	testCases := []struct {
+		StatusCode      int
+		Body            string
+		ErrorMatcher    func(err error) bool
+		ExpectedMessage string
+		ExpectedFrom    string
+	}{
+		// Case 1 ensures successful responses are no errors.
+		{
+			StatusCode:   http.StatusOK,
+			Body:         `{"code":"SUCCESS"}`,
+			ErrorMatcher: nil,
+		},
+		// Case 2 ensures microkit error response bodies are decoded.
+		{
+			StatusCode:      http.StatusNotFound,
+			Body:            `{"code":"RESOURCE_NOT_FOUND","error":"user not found","from":"api"}`,
+			ErrorMatcher:    IsResourceNotFound,
+			ExpectedMessage: "user not found",
+			ExpectedFrom:    "api",
+		},
+		// Case 3 ensures codes are matched exactly.
+		{
+			StatusCode:      http.StatusBadRequest,
+			Body:            `{"code":"INVALID_INPUT","error":"name must not be empty","from":"api"}`,
+			ErrorMatcher:    IsInvalidInput,
+			ExpectedMessage: "name must not be empty",
+			ExpectedFrom:    "api",
+		},
+		// Case 4 ensures other bodies are used as message.
+		{
+			StatusCode:      http.StatusBadGateway,
+			Body:            "upstream unavailable\n",
+			ErrorMatcher:    IsResponseError,
+			ExpectedMessage: "upstream unavailable",
+			ExpectedFrom:    "",
+		},
+		// Case 5 ensures the status text is used for empty bodies.
+		{
+			StatusCode:      http.StatusForbidden,
+			Body:            "",
+			ErrorMatcher:    IsResponseError,
+			ExpectedMessage: http.StatusText(http.StatusForbidden),
+			ExpectedFrom:    "",
+		},
+	}
+
+	for i, tc := range testCases {
+		w := httptest.NewRecorder()
+		w.WriteHeader(tc.StatusCode)
+		_, _ = w.WriteString(tc.Body)
+
+		err := ParseResponseError(w.Result())
+		if (err != nil && tc.ErrorMatcher == nil) || (tc.ErrorMatcher != nil && !tc.ErrorMatcher(err)) {
+			t.Fatal("case", i+1, "expected", true, "got", false, "error", err)
+		}
+		if tc.ErrorMatcher == nil {
+			continue
+		}
+
+		responseError := ToResponseError(err)
+		if responseError.StatusCode() != tc.StatusCode {
+			t.Fatal("case", i+1, "expected", tc.StatusCode, "got", responseError.StatusCode())
+		}
+		if responseError.Message() != tc.ExpectedMessage {
+			t.Fatal("case", i+1, "expected", tc.ExpectedMessage, "got", responseError.Message())
+		}
+		if responseError.From() != tc.ExpectedFrom {
+			t.Fatal("case", i+1, "expected", tc.ExpectedFrom, "got", responseError.From())
+		}
+		if IsResourceAlreadyExists(err) {
+			t.Fatal("case", i+1, "expected", false, "got", true)
+		}
+	}
+}
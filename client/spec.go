@@ -0,0 +1,21 @@
+package client
+
+import (
+	"net/http"
+)
+
+// Client manages the HTTP transport logic of outgoing requests to other
+// microservices.
+type Client interface {
+	// Do sends the given request and returns its response. Request IDs and
+	// trace headers found in the request context are propagated. Idempotent
+	// requests are retried in case of network errors or temporarily unavailable
+	// targets. Responses having a status code of 400 or above are returned as
+	// ResponseError as created by ParseResponseError, in which case the
+	// response body is already closed.
+	Do(req *http.Request) (*http.Response, error)
+	// HTTPClient returns the underlying HTTP client, which is configured with
+	// TLS, propagation, instrumentation and retries, but does not decode error
+	// responses.
+	HTTPClient() *http.Client
+}
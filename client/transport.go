@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/microkit/server"
+)
+
+// propagationTransport sets the request ID and trace headers found in the
+// request context on outgoing requests, unless they are already set.
+type propagationTransport struct {
+	next http.RoundTripper
+}
+
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	header := server.TraceHeadersFromContext(ctx)
+	if requestID, ok := server.RequestIDFromContext(ctx); ok {
+		header.Set(server.RequestIDHeader, requestID)
+	}
+	for k := range header {
+		if req.Header.Get(k) != "" {
+			header.Del(k)
+		}
+	}
+
+	if len(header) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	// Round trippers must not modify the given request, which is why we set the
+	// headers on a copy.
+	req = req.Clone(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// instrumentationTransport tracks counts and durations of requests per target.
+type instrumentationTransport struct {
+	next http.RoundTripper
+}
+
+func (t *instrumentationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	res, err := t.next.RoundTrip(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	method := strings.ToLower(req.Method)
+	target := req.URL.Host
+
+	requestTotal.WithLabelValues(code, method, target).Inc()
+	requestTime.WithLabelValues(code, method, target).Set(float64(time.Since(start) / time.Millisecond))
+
+	return res, err
+}
+
+// timeoutTransport cancels requests not receiving response headers within the
+// timeout. Unlike http.Client.Timeout, reading the response body is not
+// limited, which would cut off streamed responses.
+type timeoutTransport struct {
+	next http.RoundTripper
+
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.timeout, cancel)
+
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+	if !timer.Stop() {
+		if err == nil {
+			res.Body.Close()
+		}
+		cancel()
+		return nil, microerror.Maskf(timeoutError, "%s %s did not receive response headers within %s", req.Method, req.URL.String(), t.timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The context must only be canceled once the response body is closed, since
+	// canceling it aborts reading the body.
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+
+	return res, nil
+}
+
+// cancelBody cancels the context of its request once it is closed.
+type cancelBody struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryTransport retries idempotent requests in case of network errors or
+// responses indicating the target is temporarily unavailable. The backoff
+// between retries grows exponentially and is randomized.
+type retryTransport struct {
+	logger micrologger.Logger
+	next   http.RoundTripper
+
+	backoff    time.Duration
+	maxBackoff time.Duration
+	maxRetries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.maxRetries == 0 || !isRetryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		res, err := t.next.RoundTrip(r)
+		if attempt >= t.maxRetries || !shouldRetry(req.Context(), res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBodySize))
+			res.Body.Close()
+		}
+
+		backoff := t.backoff << attempt
+		if backoff <= 0 || backoff > t.maxBackoff {
+			backoff = t.maxBackoff
+		}
+		backoff = backoff/2 + rand.N(backoff/2+1) //nolint:gosec
+
+		t.logger.Log("level", "debug", "message", fmt.Sprintf("retrying %s %s in %s", req.Method, req.URL.String(), backoff), "attempt", attempt+1)
+		retryTotal.WithLabelValues(strings.ToLower(req.Method), req.URL.Host).Inc()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// isRetryable expresses whether the given request can safely be sent multiple
+// times. This is the case for requests having an idempotent method or an
+// idempotency key, as long as their body can be sent again.
+func isRetryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// shouldRetry expresses whether the outcome of a request indicates a temporary
+// failure worth retrying.
+func shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
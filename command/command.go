@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/giantswarm/microkit/command/daemon"
+	"github.com/giantswarm/microkit/command/openapi"
+	"github.com/giantswarm/microkit/command/routes"
 	"github.com/giantswarm/microkit/command/version"
 )
 
@@ -48,6 +50,39 @@ func New(config Config) (Command, error) {
 		}
 	}
 
+	var openAPICommand openapi.Command
+	{
+		c := openapi.Config{
+			DaemonCommand: daemonCommand,
+			ServerFactory: config.ServerFactory,
+
+			Description: config.Description,
+			Name:        config.Name,
+			Version:     config.Version,
+			Viper:       config.Viper,
+		}
+
+		openAPICommand, err = openapi.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var routesCommand routes.Command
+	{
+		c := routes.Config{
+			DaemonCommand: daemonCommand,
+			ServerFactory: config.ServerFactory,
+
+			Viper: config.Viper,
+		}
+
+		routesCommand, err = routes.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var versionCommand version.Command
 	{
 		versionConfig := version.Config{
@@ -69,6 +104,8 @@ func New(config Config) (Command, error) {
 		// Internals.
 		cobraCommand:   nil,
 		daemonCommand:  daemonCommand,
+		openAPICommand: openAPICommand,
+		routesCommand:  routesCommand,
 		versionCommand: versionCommand,
 	}
 
@@ -79,6 +116,8 @@ func New(config Config) (Command, error) {
 		Run:   newCommand.Execute,
 	}
 	newCommand.cobraCommand.AddCommand(newCommand.daemonCommand.CobraCommand())
+	newCommand.cobraCommand.AddCommand(newCommand.openAPICommand.CobraCommand())
+	newCommand.cobraCommand.AddCommand(newCommand.routesCommand.CobraCommand())
 	newCommand.cobraCommand.AddCommand(newCommand.versionCommand.CobraCommand())
 
 	return newCommand, nil
@@ -88,6 +127,8 @@ type command struct {
 	// Internals.
 	cobraCommand   *cobra.Command
 	daemonCommand  daemon.Command
+	openAPICommand openapi.Command
+	routesCommand  routes.Command
 	versionCommand version.Command
 }
 
@@ -103,6 +144,14 @@ func (c *command) Execute(cmd *cobra.Command, args []string) {
 	cmd.HelpFunc()(cmd, nil)
 }
 
+func (c *command) OpenAPICommand() openapi.Command {
+	return c.openAPICommand
+}
+
+func (c *command) RoutesCommand() routes.Command {
+	return c.routesCommand
+}
+
 func (c *command) VersionCommand() version.Command {
 	return c.versionCommand
 }
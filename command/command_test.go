@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/microkit/server"
+)
+
+// Test_Command_ServerFactory_Flags ensures the commands calling the server
+// factory without running the daemon accept the flags of the daemon command,
+// including the ones registered after creating the root command.
+func Test_Command_ServerFactory_Flags(t *testing.T) {
+	testCases := []struct {
+		Args []string
+	}{
+		// Case 1 ensures the openapi command accepts the daemon flags.
+		{
+			Args: []string{"openapi", "--service.name=test-service", "-o", filepath.Join(t.TempDir(), "openapi.yaml")},
+		},
+		// Case 2 ensures the routes command accepts the daemon flags.
+		{
+			Args: []string{"routes", "--server.listen.address=http://127.0.0.1:8080", "--service.name=test-service"},
+		},
+	}
+
+	for i, tc := range testCases {
+		var serviceName string
+		serverFactory := func(v *viper.Viper) server.Server {
+			serviceName = v.GetString("service.name")
+
+			e := server.NewEndpoint("test-endpoint", http.MethodGet, "/test-path", func(ctx context.Context, request struct{}) (*struct{}, error) {
+				return nil, nil
+			})
+
+			newServer, err := server.New(server.Config{
+				Logger:   microloggertest.New(),
+				Registry: prometheus.NewRegistry(),
+
+				Endpoints:     []server.Endpoint{e},
+				ListenAddress: "http://127.0.0.1:8000",
+				ServiceName:   serviceName,
+			})
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+
+			return newServer
+		}
+
+		newCommand, err := New(Config{
+			Logger:        microloggertest.New(),
+			ServerFactory: serverFactory,
+
+			Description: "test-description",
+			GitCommit:   "test-commit",
+			Name:        "test-service",
+			Source:      "test-source",
+			Version:     "test-version",
+			Viper:       viper.New(),
+		})
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		newCommand.DaemonCommand().CobraCommand().PersistentFlags().String("service.name", "", "Name of the service.")
+
+		newCommand.CobraCommand().SetArgs(tc.Args)
+		err = newCommand.CobraCommand().Execute()
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		if serviceName != "test-service" {
+			t.Fatal("case", i+1, "expected", "test-service", "got", serviceName)
+		}
+	}
+}
@@ -2,19 +2,33 @@
 package daemon
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/giantswarm/microkit/command/daemon/flag"
 	microflag "github.com/giantswarm/microkit/flag"
 	"github.com/giantswarm/microkit/server"
+	"github.com/giantswarm/microkit/systemd"
+	"github.com/giantswarm/microkit/tls"
+)
+
+const (
+	// upgradeTimeout is the time upgraded processes have to get ready before
+	// they are killed.
+	upgradeTimeout = 60 * time.Second
 )
 
 var (
@@ -59,17 +73,85 @@ func New(config Config) (Command, error) {
 
 	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Config.Dirs, []string{"."}, "List of config file directories.")
 	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Config.Files, []string{"config"}, "List of the config file names. All viper supported extensions can be used.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Server.Compression.Enabled, false, "Whether to compress responses using gzip or zstd as accepted by clients.")
+	newCommand.cobraCommand.PersistentFlags().Int64(f.Server.Compression.MaxRequestBodySize, server.DefaultCompressionMaxRequestBodySize, "Maximum size in bytes of decompressed request bodies.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Server.Compression.MinSize, server.DefaultCompressionMinSize, "Minimum size in bytes of response bodies being compressed.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Server.Compression.RequestBodies, false, "Whether to decompress gzip encoded request bodies.")
 	newCommand.cobraCommand.PersistentFlags().Bool(f.Server.Enable.Debug.Server, false, "Enable debug server at http://127.0.0.1:6060/debug.")
-	newCommand.cobraCommand.PersistentFlags().String(f.Server.Listen.Address, "http://127.0.0.1:8000", "Address used to make the server listen to.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Server.HTTP2.Cleartext, false, "Whether to serve HTTP/2 without TLS (h2c) on http:// and unix:// listen addresses.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Server.HTTP2.MaxConcurrentStreams, 0, "Maximum number of concurrent streams per HTTP/2 connection. Zero uses the default of the standard library.")
+	newCommand.cobraCommand.PersistentFlags().Int(f.Server.HTTP2.MaxReadFrameSize, 0, "Largest HTTP/2 frame in bytes the server is willing to read, between 16KiB and 16MiB. Zero uses the default of the standard library.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Server.HTTP2.PingInterval, 0, "Interval after which idle HTTP/2 connections are checked using ping frames. Zero disables health checks.")
+	newCommand.cobraCommand.PersistentFlags().Duration(f.Server.HTTP2.PingTimeout, 0, "Timeout after which HTTP/2 connections are closed in case ping frames are not answered. Zero uses the default of 15 seconds.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Server.Listen.Address, []string{"http://127.0.0.1:8000"}, "Address used to make the server listen to. Unix domain sockets can be given like unix:///run/svc.sock. Can be given multiple times to serve the same endpoints on multiple addresses.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Server.Listen.GRPCAddress, "", "Optional alternate address to serve gRPC services on, if any. Leave blank to serve them on the listen address above.")
 	newCommand.cobraCommand.PersistentFlags().String(f.Server.Listen.MetricsAddress, "", "Optional alternate address to expose metrics on at /metrics. Leave blank to use the default server (listen address above).")
+	newCommand.cobraCommand.PersistentFlags().String(f.Server.Listen.SocketMode, "0660", "Octal file mode of the unix domain socket the server listens on, if any.")
 	newCommand.cobraCommand.PersistentFlags().Bool(f.Server.Log.Access, false, "Whether to emit logs for each requested route.")
 	newCommand.cobraCommand.PersistentFlags().String(f.Server.TLS.CaFile, "", "File path of the TLS root CA file, if any.")
-	newCommand.cobraCommand.PersistentFlags().String(f.Server.TLS.CrtFile, "", "File path of the TLS public key file, if any.")
-	newCommand.cobraCommand.PersistentFlags().String(f.Server.TLS.KeyFile, "", "File path of the TLS private key file, if any.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Server.TLS.ClientCaFile, nil, "File path of the CA file used to verify client certificates, if any. Clients are required to present a certificate when given. Can be given multiple times.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Server.TLS.CrlFile, nil, "File path of the certificate revocation list used to reject revoked client certificates, if any. Can be given multiple times.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Server.TLS.CrtFile, nil, "File path of the TLS public key file or PKCS#12 bundle (.p12, .pfx), if any. Can be given multiple times to serve certificates selected by SNI, the first one being the default.")
+	newCommand.cobraCommand.PersistentFlags().Bool(f.Server.TLS.Dev.SelfSigned, false, "Generate an ephemeral CA and server certificate for https listen addresses. Only meant for local development.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Server.TLS.Dev.Dir, "", "Optional directory to write the generated development CA and server certificate to.")
+	newCommand.cobraCommand.PersistentFlags().StringSlice(f.Server.TLS.KeyFile, nil, "File path of the TLS private key file, if any. Must be given once for every TLS public key file which is not a PKCS#12 bundle.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Server.TLS.Passphrase.Env, "", "Name of the environment variable holding the passphrase of encrypted TLS private keys and PKCS#12 bundles, if any.")
+	newCommand.cobraCommand.PersistentFlags().String(f.Server.TLS.Passphrase.File, "", "File path of the passphrase of encrypted TLS private keys and PKCS#12 bundles, if any.")
 
 	return newCommand, nil
 }
 
+// ParseFlags parses the given arguments using the flags of the given command
+// and the persistent flags of the given daemon command, and merges them with
+// the environment variables and config files into the given viper, like the
+// daemon command does before calling the server factory. Commands calling the
+// server factory without running the daemon use it, so that the server is
+// created using the same configuration. The flag parsing of their cobra
+// command must be disabled via cobra.Command.DisableFlagParsing, since the
+// daemon flags are only added when parsing. That way flags registered on the
+// daemon command after creating it are accepted as well. It returns
+// pflag.ErrHelp in case the help flag is given.
+func ParseFlags(v *viper.Viper, daemonCommand Command, cmd *cobra.Command, args []string) error {
+	fs := cmd.Flags()
+	fs.AddFlagSet(daemonCommand.CobraCommand().PersistentFlags())
+
+	err := fs.Parse(args)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	help, err := fs.GetBool("help")
+	if err == nil && help {
+		return microerror.Mask(pflag.ErrHelp)
+	}
+
+	err = mergeFlags(v, fs)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// mergeFlags applies the given flags to the given viper and merges them with
+// the environment variables and config files, if any.
+func mergeFlags(v *viper.Viper, fs *pflag.FlagSet) error {
+	// We have to parse the flags given via command line first. Only that way we
+	// are able to use the flag configuration for the location of configuration
+	// directories and files in the next step below.
+	microflag.Parse(v, fs)
+
+	// Merge the given command line flags with the given environment variables and
+	// the given config files, if any. The merged flags will be applied to the
+	// given viper.
+	err := microflag.Merge(v, fs, v.GetStringSlice(f.Config.Dirs), v.GetStringSlice(f.Config.Files))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
 type command struct {
 	// Dependencies.
 	logger        micrologger.Logger
@@ -87,61 +169,155 @@ func (c *command) CobraCommand() *cobra.Command {
 }
 
 func (c *command) Execute(cmd *cobra.Command, args []string) {
-	// We have to parse the flags given via command line first. Only that way we
-	// are able to use the flag configuration for the location of configuration
-	// directories and files in the next step below.
-	microflag.Parse(c.viper, cmd.Flags())
-
-	// Merge the given command line flags with the given environment variables and
-	// the given config files, if any. The merged flags will be applied to the
-	// given viper.
-	err := microflag.Merge(c.viper, cmd.Flags(), c.viper.GetStringSlice(f.Config.Dirs), c.viper.GetStringSlice(f.Config.Files))
+	err := mergeFlags(c.viper, cmd.Flags())
 	if err != nil {
 		panic(err)
 	}
 
+	var handoff map[string][]net.Listener
 	var newServer server.Server
 	{
 		serverConfig := c.serverFactory(c.viper).Config()
 
+		if !serverConfig.Compression {
+			serverConfig.Compression = c.viper.GetBool(f.Server.Compression.Enabled)
+		}
+		if serverConfig.CompressionMaxRequestBodySize == 0 {
+			serverConfig.CompressionMaxRequestBodySize = c.viper.GetInt64(f.Server.Compression.MaxRequestBodySize)
+		}
+		if serverConfig.CompressionMinSize == 0 {
+			serverConfig.CompressionMinSize = c.viper.GetInt(f.Server.Compression.MinSize)
+		}
+		if !serverConfig.CompressionRequestBodies {
+			serverConfig.CompressionRequestBodies = c.viper.GetBool(f.Server.Compression.RequestBodies)
+		}
 		serverConfig.EnableDebugServer = c.viper.GetBool(f.Server.Enable.Debug.Server)
 		serverConfig.LogAccess = c.viper.GetBool(f.Server.Log.Access)
-		if serverConfig.ListenAddress == "" {
-			serverConfig.ListenAddress = c.viper.GetString(f.Server.Listen.Address)
+		if !serverConfig.HTTP2Cleartext {
+			serverConfig.HTTP2Cleartext = c.viper.GetBool(f.Server.HTTP2.Cleartext)
+		}
+		if serverConfig.HTTP2MaxConcurrentStreams == 0 {
+			serverConfig.HTTP2MaxConcurrentStreams = c.viper.GetInt(f.Server.HTTP2.MaxConcurrentStreams)
+		}
+		if serverConfig.HTTP2MaxReadFrameSize == 0 {
+			serverConfig.HTTP2MaxReadFrameSize = c.viper.GetInt(f.Server.HTTP2.MaxReadFrameSize)
+		}
+		if serverConfig.HTTP2PingInterval == 0 {
+			serverConfig.HTTP2PingInterval = c.viper.GetDuration(f.Server.HTTP2.PingInterval)
+		}
+		if serverConfig.HTTP2PingTimeout == 0 {
+			serverConfig.HTTP2PingTimeout = c.viper.GetDuration(f.Server.HTTP2.PingTimeout)
+		}
+		if serverConfig.ListenAddress == "" && len(serverConfig.ListenAddresses) == 0 {
+			for i, a := range c.viper.GetStringSlice(f.Server.Listen.Address) {
+				if i == 0 {
+					serverConfig.ListenAddress = a
+				} else {
+					serverConfig.ListenAddresses = append(serverConfig.ListenAddresses, server.ListenAddress{Address: a})
+				}
+			}
+		}
+		if serverConfig.GRPCListenAddress == "" {
+			serverConfig.GRPCListenAddress = c.viper.GetString(f.Server.Listen.GRPCAddress)
 		}
 		if serverConfig.ListenMetricsAddress == "" {
 			serverConfig.ListenMetricsAddress = c.viper.GetString(f.Server.Listen.MetricsAddress)
 		}
+		if serverConfig.ListenSocketMode == 0 {
+			socketMode, err := newSocketMode(c.viper.GetString(f.Server.Listen.SocketMode))
+			if err != nil {
+				panic(err)
+			}
+
+			serverConfig.ListenSocketMode = socketMode
+		}
 		if serverConfig.TLSCAFile == "" {
 			serverConfig.TLSCAFile = c.viper.GetString(f.Server.TLS.CaFile)
 		}
-		if serverConfig.TLSCrtFile == "" {
-			serverConfig.TLSCrtFile = c.viper.GetString(f.Server.TLS.CrtFile)
+		if len(serverConfig.TLSClientCAFiles) == 0 {
+			serverConfig.TLSClientCAFiles = c.viper.GetStringSlice(f.Server.TLS.ClientCaFile)
 		}
-		if serverConfig.TLSKeyFile == "" {
-			serverConfig.TLSKeyFile = c.viper.GetString(f.Server.TLS.KeyFile)
+		if len(serverConfig.TLSCRLFiles) == 0 {
+			serverConfig.TLSCRLFiles = c.viper.GetStringSlice(f.Server.TLS.CrlFile)
+		}
+		if serverConfig.TLSCrtFile == "" && serverConfig.TLSKeyFile == "" && len(serverConfig.TLSKeyPairs) == 0 {
+			keyPairs, err := newKeyPairs(c.viper.GetStringSlice(f.Server.TLS.CrtFile), c.viper.GetStringSlice(f.Server.TLS.KeyFile))
+			if err != nil {
+				panic(err)
+			}
+
+			for i, p := range keyPairs {
+				if i == 0 {
+					serverConfig.TLSCrtFile = p.Cert
+					serverConfig.TLSKeyFile = p.Key
+				} else {
+					serverConfig.TLSKeyPairs = append(serverConfig.TLSKeyPairs, p)
+				}
+			}
+		}
+		if serverConfig.TLSPassphrase == (tls.Passphrase{}) {
+			serverConfig.TLSPassphrase = tls.Passphrase{
+				Env:  c.viper.GetString(f.Server.TLS.Passphrase.Env),
+				File: c.viper.GetString(f.Server.TLS.Passphrase.File),
+			}
+		}
+		if !serverConfig.TLSDevSelfSigned {
+			serverConfig.TLSDevSelfSigned = c.viper.GetBool(f.Server.TLS.Dev.SelfSigned)
+		}
+		if serverConfig.TLSDevSelfSignedDir == "" {
+			serverConfig.TLSDevSelfSignedDir = c.viper.GetString(f.Server.TLS.Dev.Dir)
+		}
+
+		handoff, err = newListeners(&serverConfig)
+		if err != nil {
+			panic(err)
 		}
 
 		newServer, err = server.New(serverConfig)
 		if err != nil {
 			panic(err)
 		}
-		go newServer.Boot()
+		newServer.Boot()
+	}
+
+	err = systemd.Notify(systemd.Ready)
+	if err != nil {
+		c.logger.Log("level", "warning", "message", "notifying systemd failed", "stack", fmt.Sprintf("%#v", err))
 	}
 
+	go c.notifyWatchdog()
+
 	// Listen to OS signals. Pressing Ctrl+C produces SIGINT. SIGTERM handled
 	// here for graceful HTTP server shutdown and return of successful exit
 	// status.
 	listener := make(chan os.Signal, 2)
 	signal.Notify(listener, syscall.SIGINT, syscall.SIGTERM)
 
-	<-listener
+	// Listen to the upgrade signal, which is SIGUSR2 on unix systems. The
+	// listeners are handed over to a new process of the current executable and
+	// this process shuts down once the new one is ready.
+	upgrade := make(chan os.Signal, 1)
+	if len(systemd.UpgradeSignals) > 0 {
+		signal.Notify(upgrade, systemd.UpgradeSignals...)
+	}
+
+	upgraded := c.waitForShutdown(listener, upgrade, handoff)
 
 	go func() {
 		var wg sync.WaitGroup
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+
+			// The upgraded process is the main process of the service already,
+			// which is why we must not tell systemd about stopping.
+			if !upgraded {
+				err := systemd.Notify(systemd.Stopping)
+				if err != nil {
+					c.logger.Log("level", "warning", "message", "notifying systemd failed", "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
 			newServer.Shutdown()
 		}()
 
@@ -153,3 +329,171 @@ func (c *command) Execute(cmd *cobra.Command, args []string) {
 
 	os.Exit(0)
 }
+
+// notifyWatchdog keeps notifying systemd in case the watchdog of the service
+// is enabled.
+func (c *command) notifyWatchdog() {
+	interval, err := systemd.WatchdogInterval()
+	if err != nil {
+		c.logger.Log("level", "warning", "message", "reading systemd watchdog interval failed", "stack", fmt.Sprintf("%#v", err))
+		return
+	}
+	if interval == 0 {
+		return
+	}
+
+	for range time.Tick(interval / 2) {
+		err := systemd.Notify(systemd.Watchdog)
+		if err != nil {
+			c.logger.Log("level", "warning", "message", "notifying systemd failed", "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+}
+
+// waitForShutdown blocks until one of the given shutdown signals is received
+// or the given listeners were handed over to an upgraded process. It
+// expresses whether the process was upgraded. Failed upgrades are logged and
+// the process keeps serving.
+func (c *command) waitForShutdown(shutdown, upgrade <-chan os.Signal, listeners map[string][]net.Listener) bool {
+	for {
+		select {
+		case <-shutdown:
+			return false
+		case <-upgrade:
+			c.logger.Log("level", "debug", "message", "upgrading process")
+
+			ctx, cancel := context.WithTimeout(context.Background(), upgradeTimeout)
+			err := systemd.Upgrade(ctx, listeners)
+			cancel()
+			if err != nil {
+				c.logger.Log("level", "error", "message", "upgrading process failed", "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+
+			c.logger.Log("level", "debug", "message", "upgraded process")
+
+			return true
+		}
+	}
+}
+
+// newListeners sets the listeners of the given server configuration, which are
+// either passed via systemd socket activation, by the process we are upgraded
+// from or created upfront. That way we are always able to hand them over to
+// upgraded processes. The listeners are returned mapped by their names.
+func newListeners(serverConfig *server.Config) (map[string][]net.Listener, error) {
+	listeners, err := systemd.Listeners()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Passed listeners are assigned to the configured listen addresses in
+	// order. Additional ones are served as well.
+	main := listeners[systemd.ListenerMain]
+	if serverConfig.Listener == nil && len(main) > 0 && (serverConfig.ListenAddress != "" || len(serverConfig.ListenAddresses) == 0) {
+		serverConfig.Listener, main = main[0], main[1:]
+	}
+	for i := range serverConfig.ListenAddresses {
+		if serverConfig.ListenAddresses[i].Listener == nil && len(main) > 0 {
+			serverConfig.ListenAddresses[i].Listener, main = main[0], main[1:]
+		}
+	}
+	for _, l := range main {
+		serverConfig.ListenAddresses = append(serverConfig.ListenAddresses, server.ListenAddress{Listener: l})
+	}
+
+	if serverConfig.Listener == nil && serverConfig.ListenAddress != "" {
+		serverConfig.Listener, err = server.NewListener(serverConfig.ListenAddress, serverConfig.ListenSocketMode)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	for i, a := range serverConfig.ListenAddresses {
+		if a.Listener == nil {
+			serverConfig.ListenAddresses[i].Listener, err = server.NewListener(a.Address, serverConfig.ListenSocketMode)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+	}
+
+	if len(serverConfig.GRPCServices) > 0 {
+		if serverConfig.GRPCListener == nil && len(listeners[systemd.ListenerGRPC]) > 0 {
+			serverConfig.GRPCListener = listeners[systemd.ListenerGRPC][0]
+		}
+		if serverConfig.GRPCListener == nil && serverConfig.GRPCListenAddress != "" {
+			serverConfig.GRPCListener, err = server.NewListener(serverConfig.GRPCListenAddress, serverConfig.ListenSocketMode)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+	}
+
+	if serverConfig.MetricsListener == nil && len(listeners[systemd.ListenerMetrics]) > 0 {
+		serverConfig.MetricsListener = listeners[systemd.ListenerMetrics][0]
+	}
+	if serverConfig.MetricsListener == nil && serverConfig.ListenMetricsAddress != "" {
+		serverConfig.MetricsListener, err = server.NewListener(serverConfig.ListenMetricsAddress, serverConfig.ListenSocketMode)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	handoff := map[string][]net.Listener{}
+	if serverConfig.Listener != nil {
+		handoff[systemd.ListenerMain] = append(handoff[systemd.ListenerMain], serverConfig.Listener)
+	}
+	for _, a := range serverConfig.ListenAddresses {
+		handoff[systemd.ListenerMain] = append(handoff[systemd.ListenerMain], a.Listener)
+	}
+	if serverConfig.GRPCListener != nil {
+		handoff[systemd.ListenerGRPC] = []net.Listener{serverConfig.GRPCListener}
+	}
+	if serverConfig.MetricsListener != nil {
+		handoff[systemd.ListenerMetrics] = []net.Listener{serverConfig.MetricsListener}
+	}
+
+	return handoff, nil
+}
+
+// newKeyPairs pairs the given certificate files with the given key files. Key
+// files are assigned in order to all certificate files which are not PKCS#12
+// bundles, since bundles already contain their key.
+func newKeyPairs(crtFiles, keyFiles []string) ([]tls.KeyPair, error) {
+	var keyPairs []tls.KeyPair
+
+	for _, crtFile := range crtFiles {
+		p := tls.KeyPair{Cert: crtFile}
+
+		if !tls.IsPKCS12Bundle(crtFile) {
+			if len(keyFiles) == 0 {
+				return nil, microerror.Maskf(invalidFlagError, "%s must be given for every %s which is not a PKCS#12 bundle", f.Server.TLS.KeyFile, f.Server.TLS.CrtFile)
+			}
+
+			p.Key, keyFiles = keyFiles[0], keyFiles[1:]
+		}
+
+		keyPairs = append(keyPairs, p)
+	}
+
+	if len(keyFiles) > 0 {
+		return nil, microerror.Maskf(invalidFlagError, "%s must not be given more often than %s", f.Server.TLS.KeyFile, f.Server.TLS.CrtFile)
+	}
+
+	return keyPairs, nil
+}
+
+// newSocketMode parses the given octal file mode of unix domain sockets. The
+// empty string results in the default mode of the server.
+func newSocketMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, microerror.Maskf(invalidFlagError, "%s must be an octal file mode like 0660", f.Server.Listen.SocketMode)
+	}
+
+	return os.FileMode(m), nil
+}
@@ -0,0 +1,8 @@
+package compression
+
+type Compression struct {
+	Enabled            string
+	MaxRequestBodySize string
+	MinSize            string
+	RequestBodies      string
+}
@@ -0,0 +1,9 @@
+package http2
+
+type HTTP2 struct {
+	Cleartext            string
+	MaxConcurrentStreams string
+	MaxReadFrameSize     string
+	PingInterval         string
+	PingTimeout          string
+}
@@ -2,5 +2,7 @@ package listen
 
 type Listen struct {
 	Address        string
+	GRPCAddress    string
 	MetricsAddress string
+	SocketMode     string
 }
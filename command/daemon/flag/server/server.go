@@ -1,15 +1,19 @@
 package server
 
 import (
+	"github.com/giantswarm/microkit/command/daemon/flag/server/compression"
 	"github.com/giantswarm/microkit/command/daemon/flag/server/enable"
+	"github.com/giantswarm/microkit/command/daemon/flag/server/http2"
 	"github.com/giantswarm/microkit/command/daemon/flag/server/listen"
 	"github.com/giantswarm/microkit/command/daemon/flag/server/log"
 	"github.com/giantswarm/microkit/command/daemon/flag/server/tls"
 )
 
 type Server struct {
-	Enable enable.Enable
-	Listen listen.Listen
-	Log    log.Log
-	TLS    tls.TLS
+	Compression compression.Compression
+	Enable      enable.Enable
+	HTTP2       http2.HTTP2
+	Listen      listen.Listen
+	Log         log.Log
+	TLS         tls.TLS
 }
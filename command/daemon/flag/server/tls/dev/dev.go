@@ -0,0 +1,6 @@
+package dev
+
+type Dev struct {
+	Dir        string
+	SelfSigned string
+}
@@ -0,0 +1,6 @@
+package passphrase
+
+type Passphrase struct {
+	Env  string
+	File string
+}
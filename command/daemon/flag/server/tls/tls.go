@@ -1,7 +1,16 @@
 package tls
 
+import (
+	"github.com/giantswarm/microkit/command/daemon/flag/server/tls/dev"
+	"github.com/giantswarm/microkit/command/daemon/flag/server/tls/passphrase"
+)
+
 type TLS struct {
-	CaFile  string
-	CrtFile string
-	KeyFile string
+	CaFile       string
+	ClientCaFile string
+	CrlFile      string
+	CrtFile      string
+	Dev          dev.Dev
+	KeyFile      string
+	Passphrase   passphrase.Passphrase
 }
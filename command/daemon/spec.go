@@ -7,6 +7,11 @@ import (
 	"github.com/giantswarm/microkit/server"
 )
 
+// ServerFactory creates the server of the microservice using the given viper,
+// which holds the flags of the daemon command merged with the environment
+// variables and config files. It is also called by the openapi and routes
+// commands, which only inspect the server configuration, so it must not start
+// anything besides creating the server.
 type ServerFactory func(v *viper.Viper) server.Server
 
 // Command represents the daemon command for any microservice.
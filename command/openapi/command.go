@@ -0,0 +1,182 @@
+// Package openapi implements the openapi command for any microservice, which
+// writes the OpenAPI document describing the endpoints of the microservice.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/giantswarm/microkit/command/daemon"
+	"github.com/giantswarm/microkit/server"
+)
+
+const (
+	outputFlag = "output"
+)
+
+// Config represents the configuration used to create a new openapi command.
+type Config struct {
+	// DaemonCommand is the optional daemon command whose flags are accepted
+	// in addition to the flags of the openapi command, so that the server
+	// factory is called with the same configuration as when running the
+	// daemon.
+	DaemonCommand daemon.Command
+	ServerFactory daemon.ServerFactory
+
+	// Description, Name and Version describe the API in case the server
+	// configuration does not set server.Config.OpenAPIInfo.
+	Description string
+	Name        string
+	Version     string
+	Viper       *viper.Viper
+}
+
+// New creates a new openapi command.
+func New(config Config) (Command, error) {
+	if config.ServerFactory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ServerFactory must not be empty", config)
+	}
+	if config.Viper == nil {
+		config.Viper = viper.New()
+	}
+
+	newCommand := &command{
+		daemonCommand: config.DaemonCommand,
+		serverFactory: config.ServerFactory,
+
+		cobraCommand: nil,
+
+		description: config.Description,
+		name:        config.Name,
+		version:     config.Version,
+		viper:       config.Viper,
+	}
+
+	newCommand.cobraCommand = &cobra.Command{
+		Use:   "openapi",
+		Short: "Write the OpenAPI document of the microservice.",
+		Long:  "Write the OpenAPI document describing the endpoints of the microservice. The document is written as JSON in case the output file ends with .json, and as YAML otherwise.",
+		Run:   newCommand.Execute,
+	}
+
+	// The daemon flags are parsed by the command itself, since they are only
+	// known once it is executed, see daemon.ParseFlags.
+	newCommand.cobraCommand.DisableFlagParsing = config.DaemonCommand != nil
+
+	newCommand.cobraCommand.Flags().StringP(outputFlag, "o", "openapi.yaml", "File the OpenAPI document is written to. Use - to write to stdout.")
+
+	return newCommand, nil
+}
+
+type command struct {
+	// Dependencies.
+	daemonCommand daemon.Command
+	serverFactory daemon.ServerFactory
+
+	// Internals.
+	cobraCommand *cobra.Command
+
+	// Settings.
+	description string
+	name        string
+	version     string
+	viper       *viper.Viper
+}
+
+func (c *command) CobraCommand() *cobra.Command {
+	return c.cobraCommand
+}
+
+func (c *command) Execute(cmd *cobra.Command, args []string) {
+	if c.daemonCommand != nil {
+		err := daemon.ParseFlags(c.viper, c.daemonCommand, cmd, args)
+		if errors.Is(err, pflag.ErrHelp) {
+			_ = cmd.Help()
+			return
+		} else if err != nil {
+			panic(err)
+		}
+	}
+
+	output, err := cmd.Flags().GetString(outputFlag)
+	if err != nil {
+		panic(err)
+	}
+
+	b, err := c.document(output)
+	if err != nil {
+		panic(err)
+	}
+
+	if output == "-" {
+		fmt.Printf("%s", b)
+		return
+	}
+
+	// The document is meant to be published, which is why it is readable by
+	// everyone.
+	//nolint:gosec
+	err = os.WriteFile(output, b, 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// document returns the encoded OpenAPI document of the endpoints of the
+// server created by the server factory, using the format of the given output
+// file.
+func (c *command) document(output string) ([]byte, error) {
+	serverConfig := c.serverFactory(c.viper).Config()
+
+	info := serverConfig.OpenAPIInfo
+	if info.Description == "" {
+		info.Description = c.description
+	}
+	if info.Title == "" {
+		info.Title = c.name
+	}
+	if info.Version == "" {
+		info.Version = c.version
+	}
+	if info.Version == "" {
+		info.Version = server.DefaultOpenAPIVersion
+	}
+
+	document, err := server.NewOpenAPIDocument(info, serverConfig.Endpoints)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if filepath.Ext(output) == ".json" {
+		b, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return append(b, '\n'), nil
+	}
+
+	var buffer bytes.Buffer
+	e := yaml.NewEncoder(&buffer)
+	e.SetIndent(2)
+	err = e.Encode(document)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	err = e.Close()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,13 @@
+package openapi
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Command represents the openapi command for any microservice.
+type Command interface {
+	// CobraCommand returns the actual cobra command for the openapi command.
+	CobraCommand() *cobra.Command
+	// Execute represents the cobra run method.
+	Execute(cmd *cobra.Command, args []string)
+}
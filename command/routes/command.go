@@ -0,0 +1,123 @@
+// Package routes implements the routes command for any microservice, which
+// prints the routes the server of the microservice registers without running
+// it.
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/microkit/command/daemon"
+	"github.com/giantswarm/microkit/server"
+)
+
+// Config represents the configuration used to create a new routes command.
+type Config struct {
+	// DaemonCommand is the optional daemon command whose flags are accepted
+	// in addition to the flags of the routes command, so that the server
+	// factory is called with the same configuration as when running the
+	// daemon.
+	DaemonCommand daemon.Command
+	ServerFactory daemon.ServerFactory
+
+	Viper *viper.Viper
+}
+
+// New creates a new routes command.
+func New(config Config) (Command, error) {
+	if config.ServerFactory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ServerFactory must not be empty", config)
+	}
+	if config.Viper == nil {
+		config.Viper = viper.New()
+	}
+
+	newCommand := &command{
+		daemonCommand: config.DaemonCommand,
+		serverFactory: config.ServerFactory,
+
+		cobraCommand: nil,
+
+		viper: config.Viper,
+	}
+
+	newCommand.cobraCommand = &cobra.Command{
+		Use:   "routes",
+		Short: "Print the routes of the microservice.",
+		Long:  "Print the name, method, path, number of middlewares and instrumentation of every route the server of the microservice registers, in the order they are matched, without starting the daemon.",
+		Run:   newCommand.Execute,
+	}
+
+	// The daemon flags are parsed by the command itself, since they are only
+	// known once it is executed, see daemon.ParseFlags.
+	newCommand.cobraCommand.DisableFlagParsing = config.DaemonCommand != nil
+
+	return newCommand, nil
+}
+
+type command struct {
+	// Dependencies.
+	daemonCommand daemon.Command
+	serverFactory daemon.ServerFactory
+
+	// Internals.
+	cobraCommand *cobra.Command
+
+	// Settings.
+	viper *viper.Viper
+}
+
+func (c *command) CobraCommand() *cobra.Command {
+	return c.cobraCommand
+}
+
+func (c *command) Execute(cmd *cobra.Command, args []string) {
+	if c.daemonCommand != nil {
+		err := daemon.ParseFlags(c.viper, c.daemonCommand, cmd, args)
+		if errors.Is(err, pflag.ErrHelp) {
+			_ = cmd.Help()
+			return
+		} else if err != nil {
+			panic(err)
+		}
+	}
+
+	routes := server.NewRoutes(c.serverFactory(c.viper).Config())
+
+	err := writeRoutes(os.Stdout, routes)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// writeRoutes writes the given routes as table to the given writer. Routes
+// matching every method are shown using *.
+func writeRoutes(w io.Writer, routes []server.Route) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tMETHOD\tPATH\tMIDDLEWARES\tINSTRUMENTED")
+	for _, r := range routes {
+		method := r.Method
+		if method == "" {
+			method = "*"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", r.Name, method, r.Path, r.Middlewares, strconv.FormatBool(r.Instrumented))
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Command represents the routes command for any microservice.
+type Command interface {
+	// CobraCommand returns the actual cobra command for the routes command.
+	CobraCommand() *cobra.Command
+	// Execute represents the cobra run method.
+	Execute(cmd *cobra.Command, args []string)
+}
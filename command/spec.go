@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/giantswarm/microkit/command/daemon"
+	"github.com/giantswarm/microkit/command/openapi"
+	"github.com/giantswarm/microkit/command/routes"
 	"github.com/giantswarm/microkit/command/version"
 )
 
@@ -15,6 +17,10 @@ type Command interface {
 	DaemonCommand() daemon.Command
 	// Execute represents the cobra run method.
 	Execute(cmd *cobra.Command, args []string)
+	// OpenAPICommand returns the openapi sub command.
+	OpenAPICommand() openapi.Command
+	// RoutesCommand returns the routes sub command.
+	RoutesCommand() routes.Command
 	// VersionCommand returns the version sub command.
 	VersionCommand() version.Command
 }
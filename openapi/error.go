@@ -0,0 +1,23 @@
+package openapi
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var duplicateOperationError = &microerror.Error{
+	Kind: "duplicateOperationError",
+}
+
+// IsDuplicateOperation asserts duplicateOperationError.
+func IsDuplicateOperation(err error) bool {
+	return microerror.Cause(err) == duplicateOperationError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
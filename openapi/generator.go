@@ -0,0 +1,377 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/microkit/binding"
+)
+
+// Config represents the configuration used to create a new generator.
+type Config struct {
+	// Info describes the API of the generated document. Its title and version
+	// must not be empty.
+	Info Info
+}
+
+// New creates a new generator.
+func New(config Config) (Generator, error) {
+	if config.Info.Title == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Info.Title must not be empty", config)
+	}
+	if config.Info.Version == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Info.Version must not be empty", config)
+	}
+
+	g := &generator{
+		document: &Document{
+			Components: Components{
+				Schemas: map[string]*Schema{},
+			},
+			Info:    config.Info,
+			OpenAPI: Version,
+			Paths:   map[string]PathItem{},
+		},
+		names: map[reflect.Type]string{},
+	}
+
+	return g, nil
+}
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	pathVariablePattern = regexp.MustCompile(`\{([^}:]+):[^}]*\}`)
+	schemaNamePattern   = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+)
+
+type generator struct {
+	document *Document
+	names    map[reflect.Type]string
+}
+
+func (g *generator) AddOperation(method, path string, operation *Operation) error {
+	path = pathVariablePattern.ReplaceAllString(path, "{$1}")
+	method = strings.ToLower(method)
+
+	item, ok := g.document.Paths[path]
+	if !ok {
+		item = PathItem{}
+		g.document.Paths[path] = item
+	}
+	if _, ok := item[method]; ok {
+		return microerror.Maskf(duplicateOperationError, "operation %s %s is already added", strings.ToUpper(method), path)
+	}
+
+	item[method] = operation
+
+	return nil
+}
+
+func (g *generator) Document() *Document {
+	return g.document
+}
+
+func (g *generator) Parameters(t reflect.Type) []Parameter {
+	t = indirect(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var parameters []Parameter
+	for _, location := range []string{binding.LocationPath, binding.LocationQuery, binding.LocationHeader} {
+		for _, f := range fields(t) {
+			name := f.Tag.Get(location)
+			if name == "" || name == "-" {
+				continue
+			}
+
+			schema := g.parameterSchema(f.Type)
+
+			// Enums and defaults describe the values of slices.
+			values := schema
+			if schema.Items != nil {
+				values = schema.Items
+			}
+			if enum, ok := f.Tag.Lookup("enum"); ok {
+				for _, e := range strings.Split(enum, ",") {
+					values.Enum = append(values.Enum, strings.TrimSpace(e))
+				}
+			}
+			if d, ok := f.Tag.Lookup("default"); ok {
+				values.Default = parseDefault(values.Type, d)
+			}
+
+			parameters = append(parameters, Parameter{
+				In:       location,
+				Name:     name,
+				Required: location == binding.LocationPath || f.Tag.Get("required") == "true",
+				Schema:   schema,
+			})
+		}
+	}
+
+	return parameters
+}
+
+func (g *generator) RequestBody(t reflect.Type) *Schema {
+	t = indirect(t)
+	if t == nil {
+		return nil
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return g.Schema(t)
+	}
+
+	var bodyFields []reflect.StructField
+	var hasParameters bool
+	for _, f := range fields(t) {
+		if _, ok := f.Tag.Lookup("body"); ok {
+			return g.Schema(f.Type)
+		}
+		if isParameter(f) {
+			hasParameters = true
+			continue
+		}
+		if jsonName(f) != "" {
+			bodyFields = append(bodyFields, f)
+		}
+	}
+
+	if len(bodyFields) == 0 {
+		return nil
+	}
+	if !hasParameters {
+		return g.Schema(t)
+	}
+
+	return g.objectSchema(bodyFields)
+}
+
+func (g *generator) Schema(t reflect.Type) *Schema {
+	t = indirect(t)
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds."}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0.0
+		return &Schema{Type: "integer", Minimum: &minimum}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.objectSchema(fields(t))
+		}
+		return g.refSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// parameterSchema returns the schema of a parameter of the given type, which
+// differs from its schema in JSON documents for durations.
+func (g *generator) parameterSchema(t reflect.Type) *Schema {
+	t = indirect(t)
+	if t != nil && t.Kind() == reflect.Slice {
+		return &Schema{Type: "array", Items: g.parameterSchema(t.Elem())}
+	}
+	if t == durationType {
+		return &Schema{Type: "string", Description: "Duration like 1m30s."}
+	}
+
+	return g.Schema(t)
+}
+
+// objectSchema returns the schema of an object having the given fields. Fields
+// are required unless they are pointers or their JSON tag has the omitempty
+// option.
+func (g *generator) objectSchema(fields []reflect.StructField) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+
+	for _, f := range fields {
+		name := jsonName(f)
+		if name == "" {
+			continue
+		}
+
+		property := g.Schema(f.Type)
+		if enum, ok := f.Tag.Lookup("enum"); ok && property.Ref == "" {
+			for _, e := range strings.Split(enum, ",") {
+				property.Enum = append(property.Enum, strings.TrimSpace(e))
+			}
+		}
+		schema.Properties[name] = property
+
+		if !strings.Contains(f.Tag.Get("json"), ",omitempty") && f.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// refSchema adds the schema of the given named struct type to the components
+// of the document, unless it is already added, and returns a reference to it.
+func (g *generator) refSchema(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.schemaName(t)
+		g.names[t] = name
+
+		// The name is registered before the schema is reflected, so that
+		// recursive types reference themselves.
+		g.document.Components.Schemas[name] = &Schema{}
+		*g.document.Components.Schemas[name] = *g.objectSchema(fields(t))
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// schemaName returns a unique component name for the given named type. Types
+// of different packages having the same name are prefixed with their package
+// name.
+func (g *generator) schemaName(t reflect.Type) string {
+	name := schemaNamePattern.ReplaceAllString(t.Name(), "_")
+	if _, ok := g.document.Components.Schemas[name]; !ok {
+		return name
+	}
+
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name = schemaNamePattern.ReplaceAllString(pkg, "_") + "." + name
+
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := g.document.Components.Schemas[unique]; !ok {
+			return unique
+		}
+		unique = name + strconv.Itoa(i)
+	}
+}
+
+// fields returns the exported fields of the given struct type, including the
+// fields of embedded structs without JSON name, like encoding/json does.
+func fields(t reflect.Type) []reflect.StructField {
+	var result []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous && indirect(f.Type).Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			result = append(result, fields(indirect(f.Type))...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		result = append(result, f)
+	}
+
+	return result
+}
+
+// indirect returns the type pointers of the given type point to.
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isParameter expresses whether the given field is bound from a path
+// variable, query parameter or header.
+func isParameter(f reflect.StructField) bool {
+	for _, location := range []string{binding.LocationPath, binding.LocationQuery, binding.LocationHeader} {
+		if name := f.Tag.Get(location); name != "" && name != "-" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonName returns the name of the given field in JSON documents, which is
+// empty for fields being omitted.
+func jsonName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+
+	return name
+}
+
+// parseDefault returns the given default value of a parameter typed according
+// to the given schema type.
+func parseDefault(schemaType string, value string) interface{} {
+	switch schemaType {
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testUser struct {
+	Created  time.Time         `json:"created"`
+	Friends  []*testUser       `json:"friends,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Name     string            `json:"name"`
+	Nickname *string           `json:"nickname"`
+	Secret   string            `json:"-"`
+}
+
+type testRequest struct {
+	ID    string   `path:"id"`
+	Limit int      `query:"limit" default:"10"`
+	Order []string `query:"order" enum:"asc,desc"`
+	Token string   `header:"X-Token" required:"true"`
+
+	Name string `json:"name"`
+}
+
+func Test_Generator_Schema(t *testing.T) {
+	g, err := New(Config{Info: Info{Title: "test", Version: "1.0.0"}})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	schema := g.Schema(reflect.TypeOf(testUser{}))
+	if schema.Ref != "#/components/schemas/testUser" {
+		t.Fatal("expected", "#/components/schemas/testUser", "got", schema.Ref)
+	}
+
+	b, err := json.Marshal(g.Document().Components.Schemas["testUser"])
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	expected := `{"properties":{` +
+		`"created":{"format":"date-time","type":"string"},` +
+		`"friends":{"items":{"$ref":"#/components/schemas/testUser"},"type":"array"},` +
+		`"labels":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"name":{"type":"string"},` +
+		`"nickname":{"type":"string"}` +
+		`},"required":["created","labels","name"],"type":"object"}`
+	if string(b) != expected {
+		t.Fatal("expected", expected, "got", string(b))
+	}
+}
+
+func Test_Generator_Request(t *testing.T) {
+	g, err := New(Config{Info: Info{Title: "test", Version: "1.0.0"}})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	b, err := json.Marshal(g.Parameters(reflect.TypeOf(&testRequest{})))
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	expected := `[` +
+		`{"in":"path","name":"id","required":true,"schema":{"type":"string"}},` +
+		`{"in":"query","name":"limit","schema":{"default":10,"format":"int64","type":"integer"}},` +
+		`{"in":"query","name":"order","schema":{"items":{"enum":["asc","desc"],"type":"string"},"type":"array"}},` +
+		`{"in":"header","name":"X-Token","required":true,"schema":{"type":"string"}}` +
+		`]`
+	if string(b) != expected {
+		t.Fatal("expected", expected, "got", string(b))
+	}
+
+	b, err = json.Marshal(g.RequestBody(reflect.TypeOf(testRequest{})))
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	expected = `{"properties":{"name":{"type":"string"}},"required":["name"],"type":"object"}`
+	if string(b) != expected {
+		t.Fatal("expected", expected, "got", string(b))
+	}
+}
+
+func Test_Generator_AddOperation(t *testing.T) {
+	g, err := New(Config{Info: Info{Title: "test", Version: "1.0.0"}})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	err = g.AddOperation("GET", "/users/{id:[0-9]+}", &Operation{OperationID: "get-user"})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if g.Document().Paths["/users/{id}"]["get"].OperationID != "get-user" {
+		t.Fatal("expected", "get-user", "got", g.Document().Paths["/users/{id}"])
+	}
+
+	err = g.AddOperation("GET", "/users/{id}", &Operation{OperationID: "get-user"})
+	if !IsDuplicateOperation(err) {
+		t.Fatal("expected", true, "got", false)
+	}
+}
@@ -0,0 +1,88 @@
+// Package openapi generates OpenAPI 3 documents. Schemas are reflected from Go
+// types using their JSON struct tags, and request parameters are reflected
+// using the struct tags of the binding package, so that documents describe
+// what endpoints actually decode and encode.
+package openapi
+
+const (
+	// Version is the version of the OpenAPI specification generated documents
+	// adhere to.
+	Version = "3.0.3"
+)
+
+// Document is the root object of an OpenAPI document.
+type Document struct {
+	Components Components          `json:"components" yaml:"components"`
+	Info       Info                `json:"info" yaml:"info"`
+	OpenAPI    string              `json:"openapi" yaml:"openapi"`
+	Paths      map[string]PathItem `json:"paths" yaml:"paths"`
+}
+
+// Info describes the API of a document.
+type Info struct {
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// Components holds the reusable schemas referenced within a document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of a path.
+type PathItem map[string]*Operation
+
+// Operation describes a single endpoint.
+type Operation struct {
+	Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses" yaml:"responses"`
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Parameter describes a path variable, query parameter or header of an
+// operation.
+type Parameter struct {
+	In       string  `json:"in" yaml:"in"`
+	Name     string  `json:"name" yaml:"name"`
+	Required bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *Schema `json:"schema" yaml:"schema"`
+}
+
+// RequestBody describes the request body of an operation.
+type RequestBody struct {
+	Content  map[string]MediaType `json:"content" yaml:"content"`
+	Required bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	Description string               `json:"description" yaml:"description"`
+}
+
+// MediaType describes the body of a request or response of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema" yaml:"schema"`
+}
+
+// Schema describes a data type. Named struct types are described by schemas
+// within the components of the document, which are referenced via Ref.
+type Schema struct {
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Default              interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+}
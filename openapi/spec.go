@@ -0,0 +1,27 @@
+package openapi
+
+import (
+	"reflect"
+)
+
+// Generator builds an OpenAPI document from the operations added to it,
+// reflecting schemas and parameters from Go types.
+type Generator interface {
+	// AddOperation adds the given operation for the given HTTP method and
+	// path to the document. Paths may use gorilla/mux variables like
+	// /users/{id:[0-9]+}, whose patterns are removed.
+	AddOperation(method, path string, operation *Operation) error
+	// Document returns the document of all operations added so far.
+	Document() *Document
+	// Parameters returns the parameters of the given request type, which are
+	// its fields tagged path, query or header as understood by the binding
+	// package.
+	Parameters(t reflect.Type) []Parameter
+	// RequestBody returns the schema of the request body of the given request
+	// type, which is either its field tagged body or all of its fields not
+	// being parameters. It returns nil in case the request type has no body.
+	RequestBody(t reflect.Type) *Schema
+	// Schema returns the schema of the given type. Named struct types are
+	// added to the components of the document and referenced.
+	Schema(t reflect.Type) *Schema
+}
@@ -0,0 +1,339 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.yaml.in/yaml/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ContentTypeJSON is the content type of the JSON codec, which is used in
+	// case requests do not ask for any specific content type.
+	ContentTypeJSON = "application/json"
+	// ContentTypeMsgpack is the content type of the MessagePack codec.
+	ContentTypeMsgpack = "application/msgpack"
+	// ContentTypeProtobuf is the content type of the protobuf codec. It only
+	// supports values implementing proto.Message.
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeYAML is the content type of the YAML codec.
+	ContentTypeYAML = "application/yaml"
+)
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      []Codec
+)
+
+func init() {
+	RegisterCodec(jsonCodec{})
+	RegisterCodec(msgpackCodec{})
+	RegisterCodec(protobufCodec{})
+	RegisterCodec(yamlCodec{})
+}
+
+// RegisterCodec registers the given codec to be used by NewDecoder and
+// NewEncoder and for error bodies. A codec registered for a content type
+// which already has a codec replaces the existing one. Codecs are expected to
+// be registered during initialization.
+func RegisterCodec(codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	errorCodecs.Delete(codec.ContentType())
+
+	for i, c := range codecs {
+		if c.ContentType() == codec.ContentType() {
+			codecs[i] = codec
+			return
+		}
+	}
+
+	codecs = append(codecs, codec)
+}
+
+// CodecForContentType returns the registered codec of the given Content-Type
+// header value, if any. JSON is used in case the given content type is empty.
+func CodecForContentType(contentType string) (Codec, bool) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	for _, c := range codecs {
+		if c.ContentType() == mediaType {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+// NegotiateCodec returns the registered codec best matching the given Accept
+// header values, if any. JSON is used in case nothing specific is accepted.
+func NegotiateCodec(accept []string) (Codec, bool) {
+	type acceptedType struct {
+		mediaType string
+		quality   float64
+	}
+
+	var accepted []acceptedType
+	for _, v := range accept {
+		for _, part := range strings.Split(v, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			quality := 1.0
+			if q, ok := params["q"]; ok {
+				quality, err = strconv.ParseFloat(q, 64)
+				if err != nil {
+					continue
+				}
+			}
+			if quality <= 0 {
+				continue
+			}
+
+			accepted = append(accepted, acceptedType{mediaType: mediaType, quality: quality})
+		}
+	}
+	if len(accepted) == 0 {
+		return CodecForContentType(ContentTypeJSON)
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	for _, a := range accepted {
+		switch {
+		case a.mediaType == "*/*":
+			return CodecForContentType(ContentTypeJSON)
+		case strings.HasSuffix(a.mediaType, "/*"):
+			codecsMutex.RLock()
+			for _, c := range codecs {
+				if strings.HasPrefix(c.ContentType(), strings.TrimSuffix(a.mediaType, "*")) {
+					codecsMutex.RUnlock()
+					return c, true
+				}
+			}
+			codecsMutex.RUnlock()
+		default:
+			c, ok := CodecForContentType(a.mediaType)
+			if ok {
+				return c, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// negotiateCodec returns the codec negotiated via the Accept header of the
+// given request, or nil in case no supported content type is accepted.
+func negotiateCodec(r *http.Request) Codec {
+	codec, ok := NegotiateCodec(r.Header.Values("Accept"))
+	if !ok {
+		return nil
+	}
+
+	return codec
+}
+
+type codecKey struct{}
+
+// NewContextWithCodec returns a copy of the given context carrying the given
+// codec negotiated for the response.
+func NewContextWithCodec(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, codecKey{}, codec)
+}
+
+// CodecFromContext returns the codec negotiated for the response via the
+// Accept header of the request, if any. The server puts it into the request
+// context of every endpoint.
+func CodecFromContext(ctx context.Context) (Codec, bool) {
+	codec, ok := ctx.Value(codecKey{}).(Codec)
+	return codec, ok && codec != nil
+}
+
+// NewDecoder returns a kithttp.DecodeRequestFunc decoding request bodies into
+// the values returned by newRequest, using the codec registered for the
+// Content-Type header of the request. Requests having an unsupported content
+// type are responded with HTTP status 415 and CodeUnsupportedMediaType.
+// Request bodies which cannot be decoded are responded with HTTP status 400
+// and CodeInvalidInput.
+func NewDecoder(newRequest func() interface{}) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		codec, ok := CodecForContentType(r.Header.Get("Content-Type"))
+		if !ok {
+			return nil, microerror.Maskf(unsupportedMediaTypeError, "content type %#q is not supported", r.Header.Get("Content-Type"))
+		}
+
+		request := newRequest()
+		err := codec.Decode(r.Body, request)
+		if err != nil {
+			return nil, microerror.Maskf(invalidRequestBodyError, "%s", err.Error())
+		}
+
+		return request, nil
+	}
+}
+
+// NewEncoder returns a kithttp.EncodeResponseFunc encoding responses using the
+// codec negotiated via the Accept header of the request, see
+// CodecFromContext. Responses are written using the given status code.
+// Requests not accepting any supported content type are responded with HTTP
+// status 406 and CodeNotAcceptable.
+func NewEncoder(statusCode int) kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		codec, ok := CodecFromContext(ctx)
+		if !ok {
+			return microerror.Maskf(notAcceptableError, "no accepted content type is supported")
+		}
+
+		// The response is encoded before anything is written, so that encoding
+		// errors can still be responded with an error body.
+		buffer := bodyBufferPool.Get().(*bytes.Buffer)
+		defer func() {
+			buffer.Reset()
+			bodyBufferPool.Put(buffer)
+		}()
+
+		err := codec.Encode(buffer, response)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		w.Header().Set("Content-Type", newContentTypeHeader(codec))
+		w.WriteHeader(statusCode)
+
+		_, err = w.Write(buffer.Bytes())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+}
+
+// newContentTypeHeader returns the Content-Type header value of responses
+// encoded by the given codec.
+func newContentTypeHeader(codec Codec) string {
+	if codec.ContentType() == ContentTypeJSON {
+		return "application/json; charset=utf-8"
+	}
+
+	return codec.ContentType()
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return ContentTypeMsgpack
+}
+
+func (msgpackCodec) Decode(r io.Reader, v interface{}) error {
+	d := msgpack.NewDecoder(r)
+	d.SetCustomStructTag("json")
+	return d.Decode(v)
+}
+
+func (msgpackCodec) Encode(w io.Writer, v interface{}) error {
+	e := msgpack.NewEncoder(w)
+	e.SetCustomStructTag("json")
+	return e.Encode(v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) Decode(r io.Reader, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return microerror.Maskf(invalidCodecValueError, "%T does not implement proto.Message", v)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return proto.Unmarshal(b, m)
+}
+
+func (protobufCodec) Encode(w io.Writer, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return microerror.Maskf(invalidCodecValueError, "%T does not implement proto.Message", v)
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = w.Write(b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string {
+	return ContentTypeYAML
+}
+
+func (yamlCodec) Decode(r io.Reader, v interface{}) error {
+	return yaml.NewDecoder(r).Decode(v)
+}
+
+func (yamlCodec) Encode(w io.Writer, v interface{}) error {
+	e := yaml.NewEncoder(w)
+	err := e.Encode(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return e.Close()
+}
@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.yaml.in/yaml/v3"
+)
+
+// Test_NegotiateCodec ensures codecs are negotiated according to the quality
+// of the accepted content types.
+func Test_NegotiateCodec(t *testing.T) {
+	testCases := []struct {
+		Accept              []string
+		ExpectedContentType string
+		ExpectedOK          bool
+	}{
+		// Case 1 ensures JSON is used without Accept header.
+		{
+			Accept:              nil,
+			ExpectedContentType: ContentTypeJSON,
+			ExpectedOK:          true,
+		},
+		// Case 2 ensures JSON is used for wildcards.
+		{
+			Accept:              []string{"*/*"},
+			ExpectedContentType: ContentTypeJSON,
+			ExpectedOK:          true,
+		},
+		// Case 3 ensures specific content types are used.
+		{
+			Accept:              []string{"application/yaml"},
+			ExpectedContentType: ContentTypeYAML,
+			ExpectedOK:          true,
+		},
+		// Case 4 ensures the quality of accepted content types is respected.
+		{
+			Accept:              []string{"application/json;q=0.5, application/msgpack"},
+			ExpectedContentType: ContentTypeMsgpack,
+			ExpectedOK:          true,
+		},
+		// Case 5 ensures unsupported content types are skipped.
+		{
+			Accept:              []string{"text/html", "application/x-protobuf;q=0.1"},
+			ExpectedContentType: ContentTypeProtobuf,
+			ExpectedOK:          true,
+		},
+		// Case 6 ensures nothing is negotiated without supported content types.
+		{
+			Accept:              []string{"text/html"},
+			ExpectedContentType: "",
+			ExpectedOK:          false,
+		},
+	}
+
+	for i, tc := range testCases {
+		codec, ok := NegotiateCodec(tc.Accept)
+		if ok != tc.ExpectedOK {
+			t.Fatal("case", i+1, "expected", tc.ExpectedOK, "got", ok)
+		}
+		if ok && codec.ContentType() != tc.ExpectedContentType {
+			t.Fatal("case", i+1, "expected", tc.ExpectedContentType, "got", codec.ContentType())
+		}
+	}
+}
+
+// Test_Server_Codec ensures request and response bodies are decoded and
+// encoded using the negotiated codecs and unsupported content types are
+// responded with error bodies of the negotiated format.
+func Test_Server_Codec(t *testing.T) {
+	testCases := []struct {
+		Accept              string
+		Body                string
+		ContentType         string
+		ExpectedCode        int
+		ExpectedContentType string
+		ExpectedErrorCode   string
+	}{
+		// Case 1 ensures JSON is used by default.
+		{
+			Accept:              "",
+			Body:                `{"name":"test"}`,
+			ContentType:         "",
+			ExpectedCode:        http.StatusOK,
+			ExpectedContentType: "application/json; charset=utf-8",
+		},
+		// Case 2 ensures request and response bodies can use different codecs.
+		{
+			Accept:              "application/yaml",
+			Body:                `{"name":"test"}`,
+			ContentType:         "application/json",
+			ExpectedCode:        http.StatusOK,
+			ExpectedContentType: ContentTypeYAML,
+		},
+		// Case 3 ensures unsupported request content types are responded with
+		// 415.
+		{
+			Accept:              "",
+			Body:                `<name>test</name>`,
+			ContentType:         "application/xml",
+			ExpectedCode:        http.StatusUnsupportedMediaType,
+			ExpectedContentType: "application/json; charset=utf-8",
+			ExpectedErrorCode:   CodeUnsupportedMediaType,
+		},
+		// Case 4 ensures invalid request bodies are responded with 400 using
+		// the negotiated format.
+		{
+			Accept:              "application/yaml",
+			Body:                `{"name":`,
+			ContentType:         "application/json",
+			ExpectedCode:        http.StatusBadRequest,
+			ExpectedContentType: ContentTypeYAML,
+			ExpectedErrorCode:   CodeInvalidInput,
+		},
+		// Case 5 ensures unsupported accepted content types are responded with
+		// 406.
+		{
+			Accept:              "text/html",
+			Body:                `{"name":"test"}`,
+			ContentType:         "application/json",
+			ExpectedCode:        http.StatusNotAcceptable,
+			ExpectedContentType: "application/json; charset=utf-8",
+			ExpectedErrorCode:   CodeNotAcceptable,
+		},
+		// Case 6 ensures error bodies fall back to JSON in case the negotiated
+		// codec cannot encode them.
+		{
+			Accept:              "application/x-protobuf",
+			Body:                `{"name":"test"}`,
+			ContentType:         "application/json",
+			ExpectedCode:        http.StatusInternalServerError,
+			ExpectedContentType: "application/json; charset=utf-8",
+			ExpectedErrorCode:   CodeInternalError,
+		},
+	}
+
+	for i, tc := range testCases {
+		e := &testCodecEndpoint{
+			testEndpoint: testNewEndpoint(t).(*testEndpoint),
+		}
+		e.method = http.MethodPost
+
+		config := Config{
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			Endpoints: []Endpoint{e},
+			ErrorEncoder: func(ctx context.Context, serverError error, w http.ResponseWriter) {
+				if IsInvalidCodecValue(serverError.(ResponseError).Underlying()) {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			},
+			ListenAddress: "http://127.0.0.1:8000",
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/test-path", strings.NewReader(tc.Body))
+		if tc.Accept != "" {
+			r.Header.Set("Accept", tc.Accept)
+		}
+		if tc.ContentType != "" {
+			r.Header.Set("Content-Type", tc.ContentType)
+		}
+		w := httptest.NewRecorder()
+
+		newServer.Handler().ServeHTTP(w, r)
+
+		if w.Code != tc.ExpectedCode {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCode, "got", w.Code)
+		}
+		if w.Header().Get("Content-Type") != tc.ExpectedContentType {
+			t.Fatal("case", i+1, "expected", tc.ExpectedContentType, "got", w.Header().Get("Content-Type"))
+		}
+
+		var body map[string]interface{}
+		err = yaml.Unmarshal(w.Body.Bytes(), &body)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		if tc.ExpectedErrorCode != "" {
+			if body["code"] != tc.ExpectedErrorCode {
+				t.Fatal("case", i+1, "expected", tc.ExpectedErrorCode, "got", body["code"])
+			}
+		} else if body["name"] != "test" {
+			t.Fatal("case", i+1, "expected", "test", "got", body["name"])
+		}
+	}
+}
+
+// Test_NewErrorCodec ensures codecs are only checked once for being able to
+// encode error bodies, until a codec is registered for their content type.
+func Test_NewErrorCodec(t *testing.T) {
+	codec := &testCountingCodec{}
+	ctx := NewContextWithCodec(context.Background(), codec)
+
+	for i := 0; i < 3; i++ {
+		c := newErrorCodec(ctx)
+		if c != codec {
+			t.Fatal("expected", codec, "got", c)
+		}
+	}
+	if codec.encodes != 1 {
+		t.Fatal("expected", 1, "got", codec.encodes)
+	}
+
+	RegisterCodec(codec)
+	newErrorCodec(ctx)
+	if codec.encodes != 2 {
+		t.Fatal("expected", 2, "got", codec.encodes)
+	}
+}
+
+type testCodecRequest struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type testCodecEndpoint struct {
+	*testEndpoint
+}
+
+func (e *testCodecEndpoint) Decoder() kithttp.DecodeRequestFunc {
+	return NewDecoder(func() interface{} {
+		return &testCodecRequest{}
+	})
+}
+
+func (e *testCodecEndpoint) Encoder() kithttp.EncodeResponseFunc {
+	return NewEncoder(http.StatusOK)
+}
+
+func (e *testCodecEndpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return request, nil
+	}
+}
+
+// testCountingCodec counts how often it encodes values.
+type testCountingCodec struct {
+	encodes int
+}
+
+func (c *testCountingCodec) ContentType() string {
+	return "application/x-test-counting"
+}
+
+func (c *testCountingCodec) Decode(r io.Reader, v interface{}) error {
+	return nil
+}
+
+func (c *testCountingCodec) Encode(w io.Writer, v interface{}) error {
+	c.encodes++
+	return nil
+}
@@ -8,6 +8,9 @@ var (
 	// CodeInvalidCredentials indicates the provided credentials are not valid.
 	//nolint:gosec
 	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	// CodeNotAcceptable indicates none of the content types accepted by the
+	// client is supported (usually HTTP status 406).
+	CodeNotAcceptable = "NOT_ACCEPTABLE"
 	// CodeNotSupported indicates that the resource is not supported.
 	CodeNotSupported = "NOT_SUPPORTED"
 	// CodeNotYetAvailable indicates that the API operation used is not ready yet.
@@ -16,6 +19,13 @@ var (
 	// CodePermissionDenied indicates the provided credentials are valid, but the
 	// requested resource requires other permissions.
 	CodePermissionDenied = "PERMISSION_DENIED"
+	// CodePreconditionFailed indicates a precondition of the request like
+	// If-Match does not hold for the current state of the resource (usually
+	// HTTP status 412).
+	CodePreconditionFailed = "PRECONDITION_FAILED"
+	// CodeRequestBodyTooLarge indicates the request body exceeds its maximum
+	// size (usually HTTP status 413).
+	CodeRequestBodyTooLarge = "REQUEST_BODY_TOO_LARGE"
 	// CodeResourceAlreadyExists indicates a resource does already exist.
 	CodeResourceAlreadyExists = "RESOURCE_ALREADY_EXISTS"
 	// CodeResourceCreated indicates a resource has been created.
@@ -35,6 +45,9 @@ var (
 	// CodeImmutableAttribute indicates the provided data structure contains
 	// fields that are immutable.
 	CodeImmutableAttribute = "IMMUTABLE_ATTRIBUTE"
+	// CodeUnsupportedMediaType indicates the content type of the request body
+	// is not supported (usually HTTP status 415).
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
 	// CodeUnknownAttribute indicates the provided data structure contains
 	// unexpected fields.
 	CodeUnknownAttribute = "UNKNOWN_ATTRIBUTE"
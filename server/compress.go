@@ -0,0 +1,396 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// DefaultCompressionMinSize is the minimum size in bytes of response bodies
+	// being compressed in case no other size is configured.
+	DefaultCompressionMinSize = 1024
+	// DefaultCompressionMaxRequestBodySize is the maximum size in bytes of
+	// decompressed request bodies in case no other size is configured.
+	DefaultCompressionMaxRequestBodySize = 10 * 1024 * 1024
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+)
+
+// DefaultCompressionContentTypes are the content types of responses being
+// compressed in case no other content types are configured. Entries ending
+// with a slash match all subtypes.
+var DefaultCompressionContentTypes = []string{
+	"application/javascript",
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"application/yaml",
+	"image/svg+xml",
+	"text/",
+}
+
+// compressionEncodings are the supported content encodings in order of
+// preference, which is used in case clients accept multiple encodings with
+// the same quality.
+var compressionEncodings = []string{
+	encodingZstd,
+	encodingGzip,
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	},
+}
+
+var zstdWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedDefault))
+		return w
+	},
+}
+
+// newCompressWriter returns a response writer compressing the response body
+// written to the given response writer using the encoding negotiated with the
+// given request, if any. The returned function must be called once the
+// response is written to flush the compressed body.
+func (s *server) newCompressWriter(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	// Responses differ by the accepted encodings, regardless of whether the
+	// current response is compressed.
+	if !headerContainsToken(w.Header(), "Vary", "Accept-Encoding") {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	encoding := negotiateEncoding(r.Header.Values("Accept-Encoding"))
+	if encoding == "" || r.Method == http.MethodHead {
+		return w, func() {}
+	}
+
+	cw := &compressWriter{
+		contentTypes:   s.compressionContentTypes,
+		encoding:       encoding,
+		minSize:        s.compressionMinSize,
+		responseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+
+	return cw, cw.close
+}
+
+// compressWriter buffers the beginning of the response body until it knows
+// whether the response should be compressed. Responses are compressed in case
+// their body reaches the min size, their content type is allowed and they are
+// not encoded already.
+type compressWriter struct {
+	buffer        []byte
+	decided       bool
+	encoder       io.WriteCloser
+	headerWritten bool
+	statusCode    int
+
+	contentTypes   []string
+	encoding       string
+	minSize        int
+	responseWriter http.ResponseWriter
+}
+
+func (cw *compressWriter) Header() http.Header {
+	return cw.responseWriter.Header()
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		return cw.write(b)
+	}
+
+	cw.buffer = append(cw.buffer, b...)
+	if len(cw.buffer) < cw.minSize {
+		return len(b), nil
+	}
+
+	err := cw.decide()
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return len(b), nil
+}
+
+func (cw *compressWriter) WriteHeader(c int) {
+	if cw.headerWritten || cw.decided {
+		return
+	}
+
+	// Informational responses are passed through, since they are followed by
+	// the actual response.
+	if c >= 100 && c < 200 {
+		cw.responseWriter.WriteHeader(c)
+		return
+	}
+
+	cw.statusCode = c
+	cw.headerWritten = true
+}
+
+// Flush decides whether to compress the response based on the bytes written
+// so far and flushes them to the client.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		err := cw.decide()
+		if err != nil {
+			return
+		}
+	}
+
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	_ = http.NewResponseController(cw.responseWriter).Flush()
+}
+
+// Hijack passes through to the underlying response writer in case it
+// implements http.Hijacker.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := cw.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, microerror.Mask(http.ErrNotSupported)
+	}
+
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying response writer, which is used by
+// http.ResponseController to access e.g. deadlines of the connection.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.responseWriter
+}
+
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		err := cw.decide()
+		if err != nil {
+			return
+		}
+	}
+
+	if cw.encoder != nil {
+		_ = cw.encoder.Close()
+
+		switch e := cw.encoder.(type) {
+		case *gzip.Writer:
+			gzipWriterPool.Put(e)
+		case *zstd.Encoder:
+			zstdWriterPool.Put(e)
+		}
+		cw.encoder = nil
+	}
+}
+
+// decide writes the response header, compressing the response in case it is
+// eligible, and writes the buffered body.
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+
+	if cw.shouldCompress() {
+		h := cw.responseWriter.Header()
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		h.Set("Content-Encoding", cw.encoding)
+
+		// Strong entity tags must differ between encodings of the same
+		// representation, which is why they are weakened for compressed
+		// responses.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		switch cw.encoding {
+		case encodingGzip:
+			gw := gzipWriterPool.Get().(*gzip.Writer)
+			gw.Reset(cw.responseWriter)
+			cw.encoder = gw
+		case encodingZstd:
+			zw := zstdWriterPool.Get().(*zstd.Encoder)
+			zw.Reset(cw.responseWriter)
+			cw.encoder = zw
+		}
+	}
+
+	cw.responseWriter.WriteHeader(cw.statusCode)
+
+	b := cw.buffer
+	cw.buffer = nil
+	if len(b) == 0 {
+		return nil
+	}
+
+	_, err := cw.write(b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (cw *compressWriter) shouldCompress() bool {
+	if len(cw.buffer) < cw.minSize {
+		return false
+	}
+	if cw.statusCode == http.StatusNoContent || cw.statusCode == http.StatusNotModified {
+		return false
+	}
+
+	h := cw.responseWriter.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if l, err := strconv.Atoi(h.Get("Content-Length")); err == nil && l < cw.minSize {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buffer)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range cw.contentTypes {
+		if mediaType == t || strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cw *compressWriter) write(b []byte) (int, error) {
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+
+	return cw.responseWriter.Write(b)
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality
+// of the given Accept-Encoding header values, if any.
+func negotiateEncoding(values []string) string {
+	var encoding string
+	var quality float64
+	var wildcard float64 = -1
+
+	qualities := map[string]float64{}
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+
+			q := 1.0
+			if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err != nil {
+					continue
+				}
+				q = parsed
+			}
+
+			if name == "*" {
+				wildcard = q
+			} else {
+				qualities[name] = q
+			}
+		}
+	}
+
+	for _, e := range compressionEncodings {
+		q, ok := qualities[e]
+		if !ok {
+			q = wildcard
+		}
+		if q > quality {
+			encoding = e
+			quality = q
+		}
+	}
+
+	return encoding
+}
+
+// headerContainsToken expresses whether the comma separated values of the
+// given header contain the given token.
+func headerContainsToken(h http.Header, key string, token string) bool {
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// newDecompressedRequest replaces the body of gzip encoded requests with a
+// reader decompressing it. Invalid bodies and bodies whose decompressed size
+// exceeds the given maximum size cause reading the body to fail, so that the
+// error is handled by the decoder of the endpoint. Exceeding the maximum size
+// causes an http.MaxBytesError instead of truncating the body, which is
+// responded with HTTP status 413.
+func newDecompressedRequest(w http.ResponseWriter, r *http.Request, maxSize int64) *http.Request {
+	if !strings.EqualFold(r.Header.Get("Content-Encoding"), encodingGzip) || r.Body == nil || r.Body == http.NoBody {
+		return r
+	}
+
+	r = r.Clone(r.Context())
+	r.Body = http.MaxBytesReader(w, &gzipRequestBody{body: r.Body}, maxSize)
+	r.ContentLength = -1
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+
+	return r
+}
+
+// gzipRequestBody decompresses the wrapped request body. The gzip reader is
+// created on the first read, since creating it already reads from the body.
+type gzipRequestBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+}
+
+func (b *gzipRequestBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		r, err := gzip.NewReader(b.body)
+		if err != nil {
+			return 0, err
+		}
+		b.reader = r
+	}
+
+	return b.reader.Read(p)
+}
+
+func (b *gzipRequestBody) Close() error {
+	if b.reader != nil {
+		_ = b.reader.Close()
+	}
+
+	return b.body.Close()
+}
@@ -0,0 +1,323 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Test_Server_Compression ensures responses are compressed using the encoding
+// negotiated via Accept-Encoding, in case they are large enough, have an
+// allowed content type and their endpoint does not opt out.
+func Test_Server_Compression(t *testing.T) {
+	largeBody := `{"data":"` + strings.Repeat("a", 2048) + `"}`
+
+	testCases := []struct {
+		AcceptEncoding   string
+		Body             string
+		Compress         bool
+		ContentType      string
+		ExpectedEncoding string
+	}{
+		// Case 1 ensures responses are compressed using gzip.
+		{
+			AcceptEncoding:   "gzip",
+			Body:             largeBody,
+			Compress:         true,
+			ContentType:      "application/json",
+			ExpectedEncoding: "gzip",
+		},
+		// Case 2 ensures zstd is preferred over gzip of the same quality.
+		{
+			AcceptEncoding:   "gzip, deflate, br, zstd",
+			Body:             largeBody,
+			Compress:         true,
+			ContentType:      "application/json; charset=utf-8",
+			ExpectedEncoding: "zstd",
+		},
+		// Case 3 ensures the quality of accepted encodings is respected.
+		{
+			AcceptEncoding:   "zstd;q=0.5, gzip",
+			Body:             largeBody,
+			Compress:         true,
+			ContentType:      "application/json",
+			ExpectedEncoding: "gzip",
+		},
+		// Case 4 ensures responses are not compressed without accepted encodings.
+		{
+			AcceptEncoding:   "",
+			Body:             largeBody,
+			Compress:         true,
+			ContentType:      "application/json",
+			ExpectedEncoding: "",
+		},
+		// Case 5 ensures responses below the min size are not compressed.
+		{
+			AcceptEncoding:   "gzip",
+			Body:             `{"data":"a"}`,
+			Compress:         true,
+			ContentType:      "application/json",
+			ExpectedEncoding: "",
+		},
+		// Case 6 ensures responses of other content types are not compressed.
+		{
+			AcceptEncoding:   "gzip",
+			Body:             largeBody,
+			Compress:         true,
+			ContentType:      "image/png",
+			ExpectedEncoding: "",
+		},
+		// Case 7 ensures endpoints can opt out.
+		{
+			AcceptEncoding:   "gzip",
+			Body:             largeBody,
+			Compress:         false,
+			ContentType:      "application/json",
+			ExpectedEncoding: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		e := &testCompressEndpoint{
+			testEndpoint: testNewEndpoint(t).(*testEndpoint),
+			body:         tc.Body,
+			compress:     tc.Compress,
+			contentType:  tc.ContentType,
+		}
+
+		config := Config{
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			Compression:   true,
+			Endpoints:     []Endpoint{e},
+			ListenAddress: "http://127.0.0.1:8000",
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/test-path", nil)
+		if tc.AcceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", tc.AcceptEncoding)
+		}
+		w := httptest.NewRecorder()
+
+		newServer.Handler().ServeHTTP(w, r)
+
+		if w.Header().Get("Content-Encoding") != tc.ExpectedEncoding {
+			t.Fatal("case", i+1, "expected", tc.ExpectedEncoding, "got", w.Header().Get("Content-Encoding"))
+		}
+		if tc.Compress && !headerContainsToken(w.Header(), "Vary", "Accept-Encoding") {
+			t.Fatal("case", i+1, "expected", "Vary: Accept-Encoding", "got", w.Header().Values("Vary"))
+		}
+
+		var body io.Reader = w.Body
+		switch tc.ExpectedEncoding {
+		case "gzip":
+			body, err = gzip.NewReader(body)
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+		case "zstd":
+			body, err = zstd.NewReader(body)
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+		}
+		b, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		if string(b) != tc.Body {
+			t.Fatal("case", i+1, "expected", tc.Body, "got", string(b))
+		}
+	}
+}
+
+// Test_Server_Compression_RequestBodies ensures gzip encoded request bodies
+// are decompressed before being decoded by endpoints.
+func Test_Server_Compression_RequestBodies(t *testing.T) {
+	e := &testCompressEndpoint{
+		testEndpoint: testNewEndpoint(t).(*testEndpoint),
+		echo:         true,
+	}
+	e.method = http.MethodPost
+
+	config := Config{
+		Logger:   microloggertest.New(),
+		Registry: prometheus.NewRegistry(),
+
+		CompressionRequestBodies: true,
+		Endpoints:                []Endpoint{e},
+		ListenAddress:            "http://127.0.0.1:8000",
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	_, err = gw.Write([]byte("test-request"))
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	gw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/test-path", &b)
+	r.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	newServer.Handler().ServeHTTP(w, r)
+
+	if w.Body.String() != "test-request" {
+		t.Fatal("expected", "test-request", "got", w.Body.String())
+	}
+}
+
+// Test_Server_Compression_RequestBodies_MaxSize ensures decompressed request
+// bodies exceeding their maximum size are responded with 413 instead of being
+// decoded or truncated.
+func Test_Server_Compression_RequestBodies_MaxSize(t *testing.T) {
+	type testRequest struct {
+		Data string `json:"data"`
+	}
+
+	echoEndpoint := &testCompressEndpoint{
+		testEndpoint: testNewEndpoint(t).(*testEndpoint),
+		echo:         true,
+	}
+	echoEndpoint.method = http.MethodPost
+
+	typedEndpoint := NewEndpoint("test-typed", http.MethodPost, "/test-typed", func(ctx context.Context, request testRequest) (testRequest, error) {
+		return request, nil
+	})
+
+	testCases := []struct {
+		Body         string
+		Path         string
+		ExpectedCode int
+	}{
+		// Case 1 ensures bodies within the maximum size are decompressed.
+		{
+			Body:         `{"data":"` + strings.Repeat("a", 512) + `"}`,
+			Path:         "/test-path",
+			ExpectedCode: http.StatusOK,
+		},
+		// Case 2 ensures bodies exceeding the maximum size are rejected for
+		// endpoints reading the body themselves.
+		{
+			Body:         `{"data":"` + strings.Repeat("a", 1024*1024) + `"}`,
+			Path:         "/test-path",
+			ExpectedCode: http.StatusRequestEntityTooLarge,
+		},
+		// Case 3 ensures bodies exceeding the maximum size are rejected for
+		// typed endpoints.
+		{
+			Body:         `{"data":"` + strings.Repeat("a", 1024*1024) + `"}`,
+			Path:         "/test-typed",
+			ExpectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for i, tc := range testCases {
+		config := Config{
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			CompressionMaxRequestBodySize: 1024,
+			CompressionRequestBodies:      true,
+			Endpoints:                     []Endpoint{echoEndpoint, typedEndpoint},
+			ListenAddress:                 "http://127.0.0.1:8000",
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		var b bytes.Buffer
+		gw := gzip.NewWriter(&b)
+		_, err = gw.Write([]byte(tc.Body))
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		gw.Close()
+
+		r := httptest.NewRequest(http.MethodPost, tc.Path, &b)
+		r.Header.Set("Content-Encoding", "gzip")
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		newServer.Handler().ServeHTTP(w, r)
+
+		if w.Code != tc.ExpectedCode {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCode, "got", w.Code)
+		}
+		if tc.ExpectedCode == http.StatusOK {
+			continue
+		}
+		if !strings.Contains(w.Body.String(), CodeRequestBodyTooLarge) {
+			t.Fatal("case", i+1, "expected", CodeRequestBodyTooLarge, "got", w.Body.String())
+		}
+	}
+}
+
+type testCompressEndpoint struct {
+	*testEndpoint
+	body        string
+	compress    bool
+	contentType string
+	echo        bool
+}
+
+func (e *testCompressEndpoint) Compress() bool {
+	return e.compress
+}
+
+func (e *testCompressEndpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		if !e.echo {
+			return nil, nil
+		}
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(b), nil
+	}
+}
+
+func (e *testCompressEndpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return request, nil
+	}
+}
+
+func (e *testCompressEndpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		body := e.body
+		if e.echo {
+			body = response.(string)
+		}
+
+		w.Header().Set("Content-Type", e.contentType)
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(body))
+		return err
+	}
+}
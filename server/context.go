@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// RequestIDHeader is the HTTP header used to propagate request IDs. The
+	// server reuses the request ID given by the client or generates a new one.
+	RequestIDHeader = "X-Request-ID"
+)
+
+// TraceHeaders are the HTTP headers carrying distributed tracing information,
+// which are propagated from incoming requests to outgoing requests.
+var TraceHeaders = []string{
+	"traceparent",
+	"tracestate",
+	"b3",
+	"X-B3-TraceId",
+	"X-B3-SpanId",
+	"X-B3-ParentSpanId",
+	"X-B3-Sampled",
+	"X-B3-Flags",
+}
+
+type requestIDKey struct{}
+
+type traceHeadersKey struct{}
+
+// NewContextWithRequestID returns a copy of the given context carrying the
+// given request ID.
+func NewContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID of the given context, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// NewContextWithTraceHeaders returns a copy of the given context carrying the
+// trace headers found in the given header.
+func NewContextWithTraceHeaders(ctx context.Context, header http.Header) context.Context {
+	traceHeaders := http.Header{}
+	for _, k := range TraceHeaders {
+		if v := header.Values(k); len(v) > 0 {
+			traceHeaders[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+
+	return context.WithValue(ctx, traceHeadersKey{}, traceHeaders)
+}
+
+// TraceHeadersFromContext returns the trace headers of the given context. The
+// returned header is empty in case there are none.
+func TraceHeadersFromContext(ctx context.Context) http.Header {
+	traceHeaders, ok := ctx.Value(traceHeadersKey{}).(http.Header)
+	if !ok {
+		return http.Header{}
+	}
+
+	return traceHeaders.Clone()
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/giantswarm/microerror"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/giantswarm/microkit/binding"
+)
+
+// EndpointFunc is the typed business logic of an endpoint created via
+// NewEndpoint, receiving the decoded request and returning the response to be
+// encoded.
+type EndpointFunc[Req, Resp any] func(ctx context.Context, request Req) (Resp, error)
+
+// TypedEndpoint is an Endpoint created via NewEndpoint. It decodes requests
+// into values of type Req and encodes responses of type Resp, so that its
+// EndpointFunc does not have to deal with interface{} casts.
+type TypedEndpoint[Req, Resp any] struct {
+	description  EndpointDescription
+	endpointFunc EndpointFunc[Req, Resp]
+	method       string
+	middlewares  []kitendpoint.Middleware
+	name         string
+	path         string
+	statusCode   int
+}
+
+// NewEndpoint creates a new Endpoint registered using the given name, HTTP
+// method and path, which executes the given function. Requests are decoded
+// into values of type Req using the binding package, which reads path
+// variables, query parameters and headers as described by struct tags. The
+// request body is decoded using the codec registered for its Content-Type,
+// which is JSON by default, see NewDecoder. Invalid requests are responded
+// with HTTP status 400 and CodeInvalidInput, listing every invalid parameter.
+// Responses are encoded using the codec negotiated via the Accept header of
+// the request, see NewEncoder, and HTTP status 200 unless configured otherwise
+// via WithStatusCode. The endpoint describes its request and response types
+// in the OpenAPI document of the server, see WithDescription.
+func NewEndpoint[Req, Resp any](name, method, path string, endpointFunc EndpointFunc[Req, Resp]) *TypedEndpoint[Req, Resp] {
+	e := &TypedEndpoint[Req, Resp]{
+		description:  EndpointDescription{},
+		endpointFunc: endpointFunc,
+		method:       method,
+		middlewares:  nil,
+		name:         name,
+		path:         path,
+		statusCode:   http.StatusOK,
+	}
+
+	return e
+}
+
+// WithDescription sets the description of the endpoint in the OpenAPI
+// document of the server and returns the endpoint. The request and response
+// types and the status code of the description are derived from the endpoint.
+func (e *TypedEndpoint[Req, Resp]) WithDescription(description EndpointDescription) *TypedEndpoint[Req, Resp] {
+	e.description = description
+	return e
+}
+
+// WithMiddlewares appends the given middlewares to the middlewares of the
+// endpoint and returns the endpoint.
+func (e *TypedEndpoint[Req, Resp]) WithMiddlewares(middlewares ...kitendpoint.Middleware) *TypedEndpoint[Req, Resp] {
+	e.middlewares = append(e.middlewares, middlewares...)
+	return e
+}
+
+// WithStatusCode sets the HTTP status code of successful responses and returns
+// the endpoint. Responses having HTTP status 204 have no body.
+func (e *TypedEndpoint[Req, Resp]) WithStatusCode(statusCode int) *TypedEndpoint[Req, Resp] {
+	e.statusCode = statusCode
+	return e
+}
+
+func (e *TypedEndpoint[Req, Resp]) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		var request Req
+
+		// Pointer types are allocated, so that their fields can be set.
+		v := reflect.ValueOf(&request).Elem()
+		if v.Kind() == reflect.Pointer {
+			v.Set(reflect.New(v.Type().Elem()))
+			v = v.Elem()
+		}
+
+		// Requests without body do not need to have a supported content type.
+		// Their empty body is decoded as JSON in this case.
+		codec, ok := CodecForContentType(r.Header.Get("Content-Type"))
+		if !ok && r.ContentLength != 0 {
+			return nil, microerror.Maskf(unsupportedMediaTypeError, "content type %#q is not supported", r.Header.Get("Content-Type"))
+		} else if !ok {
+			codec, _ = CodecForContentType(ContentTypeJSON)
+		}
+
+		if v.Kind() == reflect.Struct {
+			err := binding.BindWith(r, v.Addr().Interface(), codec.Decode)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		} else if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+			err := codec.Decode(r.Body, v.Addr().Interface())
+			if errors.Is(err, io.EOF) {
+				// The body is empty, which is fine for requests only
+				// consisting of path variables and query parameters.
+			} else if IsRequestBodyTooLarge(err) {
+				return nil, microerror.Mask(err)
+			} else if err != nil {
+				return nil, microerror.Maskf(invalidRequestBodyError, "%s", err.Error())
+			}
+		}
+
+		return request, nil
+	}
+}
+
+func (e *TypedEndpoint[Req, Resp]) Describe() EndpointDescription {
+	var request Req
+	var response Resp
+
+	d := e.description
+	d.Request = request
+	d.Response = response
+	d.StatusCode = e.statusCode
+
+	return d
+}
+
+func (e *TypedEndpoint[Req, Resp]) Encoder() kithttp.EncodeResponseFunc {
+	if e.statusCode == http.StatusNoContent {
+		return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+	}
+
+	return NewEncoder(e.statusCode)
+}
+
+func (e *TypedEndpoint[Req, Resp]) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := e.endpointFunc(ctx, request.(Req))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return response, nil
+	}
+}
+
+func (e *TypedEndpoint[Req, Resp]) Method() string {
+	return e.method
+}
+
+func (e *TypedEndpoint[Req, Resp]) Middlewares() []kitendpoint.Middleware {
+	return e.middlewares
+}
+
+func (e *TypedEndpoint[Req, Resp]) Name() string {
+	return e.name
+}
+
+func (e *TypedEndpoint[Req, Resp]) Path() string {
+	return e.path
+}
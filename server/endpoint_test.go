@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Test_NewEndpoint ensures typed endpoints decode request bodies, path
+// variables and query parameters and encode their responses.
+func Test_NewEndpoint(t *testing.T) {
+	type testRequest struct {
+		ID      string        `path:"id"`
+		Limit   *int          `query:"limit"`
+		Name    string        `json:"name"`
+		Tags    []string      `query:"tag"`
+		Timeout time.Duration `query:"timeout"`
+	}
+	type testResponse struct {
+		ID      string   `json:"id"`
+		Limit   int      `json:"limit"`
+		Name    string   `json:"name"`
+		Tags    []string `json:"tags"`
+		Timeout string   `json:"timeout"`
+		Wrapped bool     `json:"wrapped"`
+	}
+
+	testCases := []struct {
+		Body              string
+		ContentType       string
+		Method            string
+		StatusCode        int
+		URL               string
+		ExpectedCode      int
+		ExpectedErrorCode string
+		ExpectedResponse  testResponse
+	}{
+		// Case 1 ensures request bodies, path variables and query parameters
+		// are decoded.
+		{
+			Body:         `{"name":"test-name"}`,
+			Method:       http.MethodPost,
+			StatusCode:   http.StatusCreated,
+			URL:          "/users/test-id?limit=5&tag=a&tag=b&timeout=1m",
+			ExpectedCode: http.StatusCreated,
+			ExpectedResponse: testResponse{
+				ID:      "test-id",
+				Limit:   5,
+				Name:    "test-name",
+				Tags:    []string{"a", "b"},
+				Timeout: "1m0s",
+				Wrapped: true,
+			},
+		},
+		// Case 2 ensures requests without body are decoded.
+		{
+			Body:         "",
+			Method:       http.MethodGet,
+			StatusCode:   http.StatusOK,
+			URL:          "/users/test-id",
+			ExpectedCode: http.StatusOK,
+			ExpectedResponse: testResponse{
+				ID:      "test-id",
+				Timeout: "0s",
+				Wrapped: true,
+			},
+		},
+		// Case 3 ensures invalid query parameters are responded with 400.
+		{
+			Body:              "",
+			Method:            http.MethodGet,
+			StatusCode:        http.StatusOK,
+			URL:               "/users/test-id?limit=many",
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: CodeInvalidInput,
+		},
+		// Case 4 ensures invalid request bodies are responded with 400.
+		{
+			Body:              `{"name":`,
+			Method:            http.MethodPost,
+			StatusCode:        http.StatusOK,
+			URL:               "/users/test-id",
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: CodeInvalidInput,
+		},
+		// Case 5 ensures requests without body are decoded regardless of their
+		// content type.
+		{
+			Body:         "",
+			ContentType:  "text/plain",
+			Method:       http.MethodGet,
+			StatusCode:   http.StatusOK,
+			URL:          "/users/test-id?limit=1",
+			ExpectedCode: http.StatusOK,
+			ExpectedResponse: testResponse{
+				ID:      "test-id",
+				Limit:   1,
+				Timeout: "0s",
+				Wrapped: true,
+			},
+		},
+		// Case 6 ensures request bodies of unsupported content types are
+		// responded with 415.
+		{
+			Body:              "name",
+			ContentType:       "text/plain",
+			Method:            http.MethodPost,
+			StatusCode:        http.StatusOK,
+			URL:               "/users/test-id",
+			ExpectedCode:      http.StatusUnsupportedMediaType,
+			ExpectedErrorCode: CodeUnsupportedMediaType,
+		},
+	}
+
+	for i, tc := range testCases {
+		e := NewEndpoint("test-endpoint", tc.Method, "/users/{id}", func(ctx context.Context, request *testRequest) (testResponse, error) {
+			response := testResponse{
+				ID:      request.ID,
+				Name:    request.Name,
+				Tags:    request.Tags,
+				Timeout: request.Timeout.String(),
+				Wrapped: ctx.Value(testEndpointKey{}) == true,
+			}
+			if request.Limit != nil {
+				response.Limit = *request.Limit
+			}
+
+			return response, nil
+		})
+		e.WithStatusCode(tc.StatusCode)
+		e.WithMiddlewares(func(next kitendpoint.Endpoint) kitendpoint.Endpoint {
+			return func(ctx context.Context, request interface{}) (interface{}, error) {
+				return next(context.WithValue(ctx, testEndpointKey{}, true), request)
+			}
+		})
+
+		config := Config{
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			Endpoints:     []Endpoint{e},
+			ListenAddress: "http://127.0.0.1:8000",
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		r := httptest.NewRequest(tc.Method, tc.URL, strings.NewReader(tc.Body))
+		if tc.ContentType != "" {
+			r.Header.Set("Content-Type", tc.ContentType)
+		}
+		w := httptest.NewRecorder()
+
+		newServer.Handler().ServeHTTP(w, r)
+
+		if w.Code != tc.ExpectedCode {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCode, "got", w.Code)
+		}
+
+		if tc.ExpectedErrorCode != "" {
+			var body map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &body)
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			if body["code"] != tc.ExpectedErrorCode {
+				t.Fatal("case", i+1, "expected", tc.ExpectedErrorCode, "got", body["code"])
+			}
+			continue
+		}
+
+		var response testResponse
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		expected, _ := json.Marshal(tc.ExpectedResponse)
+		got, _ := json.Marshal(response)
+		if string(expected) != string(got) {
+			t.Fatal("case", i+1, "expected", string(expected), "got", string(got))
+		}
+	}
+}
+
+type testEndpointKey struct{}
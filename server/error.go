@@ -1,11 +1,21 @@
 package server
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/giantswarm/microerror"
 )
 
+var invalidCodecValueError = &microerror.Error{
+	Kind: "invalidCodecValueError",
+}
+
+// IsInvalidCodecValue asserts invalidCodecValueError.
+func IsInvalidCodecValue(err error) bool {
+	return microerror.Cause(err) == invalidCodecValueError
+}
+
 var invalidConfigError = &microerror.Error{
 	Kind: "invalidConfigError",
 }
@@ -24,6 +34,24 @@ func IsInvalidContext(err error) bool {
 	return microerror.Cause(err) == invalidContextError
 }
 
+var invalidEventError = &microerror.Error{
+	Kind: "invalidEventError",
+}
+
+// IsInvalidEvent asserts invalidEventError.
+func IsInvalidEvent(err error) bool {
+	return microerror.Cause(err) == invalidEventError
+}
+
+var invalidRequestBodyError = &microerror.Error{
+	Kind: "invalidRequestBodyError",
+}
+
+// IsInvalidRequestBody asserts invalidRequestBodyError.
+func IsInvalidRequestBody(err error) bool {
+	return microerror.Cause(err) == invalidRequestBodyError
+}
+
 var invalidTransactionIDError = &microerror.Error{
 	Kind: "invalidTransactionIDError",
 }
@@ -33,6 +61,32 @@ func IsInvalidTransactionID(err error) bool {
 	return microerror.Cause(err) == invalidTransactionIDError
 }
 
+var notAcceptableError = &microerror.Error{
+	Kind: "notAcceptableError",
+}
+
+// IsNotAcceptable asserts notAcceptableError.
+func IsNotAcceptable(err error) bool {
+	return microerror.Cause(err) == notAcceptableError
+}
+
+var preconditionFailedError = &microerror.Error{
+	Kind: "preconditionFailedError",
+}
+
+// IsPreconditionFailed asserts preconditionFailedError.
+func IsPreconditionFailed(err error) bool {
+	return microerror.Cause(err) == preconditionFailedError
+}
+
+// IsRequestBodyTooLarge asserts http.MaxBytesError, which is returned when
+// reading request bodies exceeding their maximum size, e.g. decompressed
+// request bodies exceeding Config.CompressionMaxRequestBodySize.
+func IsRequestBodyTooLarge(err error) bool {
+	var maxBytesError *http.MaxBytesError
+	return errors.As(microerror.Cause(err), &maxBytesError)
+}
+
 var serverClosedError = &microerror.Error{
 	Kind: "serverClosedError",
 }
@@ -54,3 +108,12 @@ func IsServerClosed(err error) bool {
 
 	return false
 }
+
+var unsupportedMediaTypeError = &microerror.Error{
+	Kind: "unsupportedMediaTypeError",
+}
+
+// IsUnsupportedMediaType asserts unsupportedMediaTypeError.
+func IsUnsupportedMediaType(err error) bool {
+	return microerror.Cause(err) == unsupportedMediaTypeError
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+type ifMatchKey struct{}
+
+// NewContextWithIfMatch returns a copy of the given context carrying the given
+// If-Match header values, which are checked by CheckIfMatch.
+func NewContextWithIfMatch(ctx context.Context, ifMatch []string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, ifMatch)
+}
+
+// CheckIfMatch checks the If-Match precondition of the current request against
+// the given entity tag of the current state of the resource. Mutating
+// endpoints call it before applying any changes. It returns an error matched
+// by IsPreconditionFailed in case the request has an If-Match header not
+// matching the given entity tag, which is responded with HTTP status 412 and
+// CodePreconditionFailed. Requests without If-Match header always pass. An
+// empty entity tag expresses that the resource does not exist. The If-Match
+// header is put into the request context in case Config.ETags is enabled.
+func CheckIfMatch(ctx context.Context, etag string) error {
+	ifMatch, _ := ctx.Value(ifMatchKey{}).([]string)
+	if len(ifMatch) == 0 {
+		return nil
+	}
+
+	etag = newETag(etag)
+	for _, v := range ifMatch {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+
+			if t == "*" && etag != "" {
+				return nil
+			}
+			// If-Match uses the strong comparison, which never matches weak
+			// entity tags.
+			if etag != "" && t == etag && !strings.HasPrefix(etag, "W/") {
+				return nil
+			}
+		}
+	}
+
+	return microerror.Maskf(preconditionFailedError, "entity tag of resource does not match If-Match header")
+}
+
+// newETagEncoder wraps the given encoder of the given endpoint to respond to
+// conditional GET and HEAD requests. The entity tag of a response is supplied
+// by the endpoint in case it implements ETagEndpoint or sets the ETag header
+// itself. Otherwise it is computed from the response body, which requires the
+// response body to be buffered. Requests whose If-None-Match or
+// If-Modified-Since header match the response are responded with HTTP status
+// 304 without body.
+func (s *server) newETagEncoder(e Endpoint, r *http.Request) kithttp.EncodeResponseFunc {
+	encoder := e.Encoder()
+
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return encoder(ctx, w, response)
+		}
+
+		if ee, ok := e.(ETagEndpoint); ok {
+			etag, err := ee.ETag(ctx, response)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			if etag != "" {
+				w.Header().Set("ETag", newETag(etag))
+				if isNotModified(r, w.Header()) {
+					writeNotModified(w)
+					return nil
+				}
+
+				return encoder(ctx, w, response)
+			}
+		}
+
+		buffer := bodyBufferPool.Get().(*bytes.Buffer)
+		defer func() {
+			buffer.Reset()
+			bodyBufferPool.Put(buffer)
+		}()
+
+		ew := &etagWriter{
+			buffer:         buffer,
+			responseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		err := encoder(ctx, ew, response)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// Only successful responses are validated, since entity tags
+		// identify representations of resources.
+		if ew.statusCode == http.StatusOK {
+			if w.Header().Get("ETag") == "" {
+				sum := sha256.Sum256(buffer.Bytes())
+				w.Header().Set("ETag", newETag(hex.EncodeToString(sum[:16])))
+			}
+			if isNotModified(r, w.Header()) {
+				writeNotModified(w)
+				return nil
+			}
+		}
+
+		w.WriteHeader(ew.statusCode)
+		_, err = w.Write(buffer.Bytes())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+}
+
+// etagWriter buffers the response written by an encoder, so that its entity
+// tag can be computed before anything is sent to the client.
+type etagWriter struct {
+	buffer         *bytes.Buffer
+	headerWritten  bool
+	responseWriter http.ResponseWriter
+	statusCode     int
+}
+
+func (ew *etagWriter) Header() http.Header {
+	return ew.responseWriter.Header()
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	ew.headerWritten = true
+	return ew.buffer.Write(b)
+}
+
+func (ew *etagWriter) WriteHeader(c int) {
+	if ew.headerWritten {
+		return
+	}
+
+	ew.statusCode = c
+	ew.headerWritten = true
+}
+
+// isNotModified expresses whether the client already has the representation
+// described by the ETag and Last-Modified headers of the given response
+// header. If-Modified-Since is only considered without If-None-Match.
+func isNotModified(r *http.Request, h http.Header) bool {
+	ifNoneMatch := r.Header.Values("If-None-Match")
+	if len(ifNoneMatch) > 0 {
+		etag := strings.TrimPrefix(h.Get("ETag"), "W/")
+		if etag == "" {
+			return false
+		}
+
+		// If-None-Match uses the weak comparison.
+		for _, v := range ifNoneMatch {
+			for _, t := range strings.Split(v, ",") {
+				t = strings.TrimSpace(t)
+				if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(h.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// newETag returns the given entity tag quoted, unless it already is.
+func newETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+
+	return `"` + etag + `"`
+}
+
+// writeNotModified responds with HTTP status 304, removing the headers
+// describing the omitted response body.
+func writeNotModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+
+	w.WriteHeader(http.StatusNotModified)
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Test_Server_ETags ensures entity tags are supplied by endpoints or computed
+// from response bodies and conditional requests are answered accordingly.
+func Test_Server_ETags(t *testing.T) {
+	lastModified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		ETag              string
+		Header            http.Header
+		Method            string
+		ExpectedCode      int
+		ExpectedETag      string
+		ExpectedErrorCode string
+	}{
+		// Case 1 ensures entity tags are computed from response bodies.
+		{
+			ETag:         "",
+			Header:       http.Header{},
+			Method:       http.MethodGet,
+			ExpectedCode: http.StatusOK,
+			ExpectedETag: `"9454f9bfc50a72f45716314980e220c7"`,
+		},
+		// Case 2 ensures matching If-None-Match headers are responded with 304.
+		{
+			ETag:         "",
+			Header:       http.Header{"If-None-Match": {`W/"9454f9bfc50a72f45716314980e220c7"`}},
+			Method:       http.MethodGet,
+			ExpectedCode: http.StatusNotModified,
+			ExpectedETag: `"9454f9bfc50a72f45716314980e220c7"`,
+		},
+		// Case 3 ensures entity tags supplied by endpoints are used.
+		{
+			ETag:         "v1",
+			Header:       http.Header{"If-None-Match": {`"v0", "v1"`}},
+			Method:       http.MethodGet,
+			ExpectedCode: http.StatusNotModified,
+			ExpectedETag: `"v1"`,
+		},
+		// Case 4 ensures If-Modified-Since is compared to Last-Modified.
+		{
+			ETag:         "",
+			Header:       http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}},
+			Method:       http.MethodGet,
+			ExpectedCode: http.StatusNotModified,
+			ExpectedETag: `"9454f9bfc50a72f45716314980e220c7"`,
+		},
+		// Case 5 ensures matching If-Match preconditions pass.
+		{
+			ETag:         "v1",
+			Header:       http.Header{"If-Match": {`"v1"`}},
+			Method:       http.MethodPut,
+			ExpectedCode: http.StatusOK,
+			ExpectedETag: "",
+		},
+		// Case 6 ensures failing If-Match preconditions are responded with 412.
+		{
+			ETag:              "v1",
+			Header:            http.Header{"If-Match": {`"v0"`}},
+			Method:            http.MethodPut,
+			ExpectedCode:      http.StatusPreconditionFailed,
+			ExpectedETag:      "",
+			ExpectedErrorCode: CodePreconditionFailed,
+		},
+	}
+
+	for i, tc := range testCases {
+		e := &testETagEndpoint{
+			testEndpoint: testNewEndpoint(t).(*testEndpoint),
+			etag:         tc.ETag,
+			lastModified: lastModified,
+		}
+		e.method = tc.Method
+
+		config := Config{
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			Endpoints:     []Endpoint{e},
+			ETags:         true,
+			ListenAddress: "http://127.0.0.1:8000",
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		r := httptest.NewRequest(tc.Method, "/test-path", nil)
+		for k, v := range tc.Header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+
+		newServer.Handler().ServeHTTP(w, r)
+
+		if w.Code != tc.ExpectedCode {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCode, "got", w.Code)
+		}
+		if w.Header().Get("ETag") != tc.ExpectedETag {
+			t.Fatal("case", i+1, "expected", tc.ExpectedETag, "got", w.Header().Get("ETag"))
+		}
+		if tc.ExpectedCode == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Fatal("case", i+1, "expected", 0, "got", w.Body.Len())
+		}
+		if tc.ExpectedErrorCode != "" {
+			var body map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &body)
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			if body["code"] != tc.ExpectedErrorCode {
+				t.Fatal("case", i+1, "expected", tc.ExpectedErrorCode, "got", body["code"])
+			}
+		}
+	}
+}
+
+type testETagEndpoint struct {
+	*testEndpoint
+	etag         string
+	lastModified time.Time
+}
+
+func (e *testETagEndpoint) ETag(ctx context.Context, response interface{}) (string, error) {
+	return e.etag, nil
+}
+
+func (e *testETagEndpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Last-Modified", e.lastModified.Format(http.TimeFormat))
+		_, err := w.Write([]byte("test-response"))
+		return err
+	}
+}
+
+func (e *testETagEndpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if e.method == http.MethodPut {
+			err := CheckIfMatch(ctx, e.etag)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return "test-response", nil
+	}
+}
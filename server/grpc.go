@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCService is a gRPC service served next to the HTTP endpoints of the
+// server.
+type GRPCService struct {
+	// Desc is the description of the service as generated by
+	// protoc-gen-go-grpc, e.g. pb.Greeter_ServiceDesc.
+	Desc *grpc.ServiceDesc
+	// Impl is the implementation of the service.
+	Impl interface{}
+}
+
+// newGRPCServer creates the gRPC server serving the configured services. The
+// server shares request IDs, trace headers, logging, panic recovery and
+// metrics with the HTTP endpoints.
+func (s *server) newGRPCServer(services []GRPCService) *grpc.Server {
+	var options []grpc.ServerOption
+	{
+		options = append(options, grpc.ChainUnaryInterceptor(s.unaryInterceptor))
+		options = append(options, grpc.ChainStreamInterceptor(s.streamInterceptor))
+
+		// gRPC services served on their own https:// listen address terminate
+		// TLS themselves.
+		if s.grpcListen != nil && s.grpcListen.tlsConfig != nil {
+			options = append(options, grpc.Creds(credentials.NewTLS(s.grpcListen.tlsConfig)))
+		}
+	}
+
+	grpcServer := grpc.NewServer(options...)
+	for _, service := range services {
+		grpcServer.RegisterService(service.Desc, service.Impl)
+	}
+
+	return grpcServer
+}
+
+// newGRPCHandler returns an HTTP handler passing gRPC requests to the gRPC
+// server and all other requests to the given handler. That way gRPC services
+// and HTTP endpoints can be served on the same port. The read and write
+// timeouts of the HTTP server are removed for gRPC requests, since streaming
+// calls are expected to be open for longer, like they are on their own listen
+// address.
+func (s *server) newGRPCHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			rc := http.NewResponseController(w)
+			err := rc.SetReadDeadline(time.Time{})
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				s.logger.Log("level", "warning", "message", "failed removing read deadline of gRPC call", "stack", fmt.Sprintf("%#v", err))
+			}
+			err = rc.SetWriteDeadline(time.Time{})
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				s.logger.Log("level", "warning", "message", "failed removing write deadline of gRPC call", "stack", fmt.Sprintf("%#v", err))
+			}
+
+			s.grpcServer.ServeHTTP(w, r)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	ctx = s.newGRPCContext(ctx)
+
+	defer func(t time.Time) {
+		r := recover()
+		if r != nil {
+			err = s.newGRPCPanicError(info.FullMethod, r)
+		}
+
+		s.trackGRPC(info.FullMethod, t, err)
+	}(time.Now())
+
+	return handler(ctx, req)
+}
+
+func (s *server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ss = &grpcServerStream{
+		ServerStream: ss,
+		ctx:          s.newGRPCContext(ss.Context()),
+	}
+
+	defer func(t time.Time) {
+		r := recover()
+		if r != nil {
+			err = s.newGRPCPanicError(info.FullMethod, r)
+		}
+
+		s.trackGRPC(info.FullMethod, t, err)
+	}(time.Now())
+
+	return handler(srv, ss)
+}
+
+// newGRPCContext puts the request ID and trace headers of the incoming
+// metadata into the given context, the same way it is done for HTTP requests.
+// The request ID is sent back to the client as header.
+func (s *server) newGRPCContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	header := http.Header{}
+	for k, v := range md {
+		header[http.CanonicalHeaderKey(k)] = v
+	}
+
+	requestID := header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(RequestIDHeader), requestID))
+
+	ctx = NewContextWithRequestID(ctx, requestID)
+	ctx = NewContextWithTraceHeaders(ctx, header)
+
+	return ctx
+}
+
+// newGRPCPanicError logs the given recovered panic and returns the error
+// sent to the client.
+func (s *server) newGRPCPanicError(method string, r interface{}) error {
+	s.logger.Log("level", "error", "message", fmt.Sprintf("recovered panic in gRPC method %s: %v", method, r), "stack", string(debug.Stack()))
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// trackGRPC emits access logs, error logs and metrics of the given gRPC
+// method call.
+func (s *server) trackGRPC(method string, t time.Time, err error) {
+	code := status.Code(err).String()
+
+	if err != nil {
+		s.logger.Log("level", "error", "message", "stop gRPC method processing due to error", "method", method, "stack", fmt.Sprintf("%#v", err))
+		s.metrics.errorTotal.WithLabelValues().Inc()
+	}
+	if s.logAccess {
+		s.logger.Log("code", code, "level", "debug", "message", "tracking access log", "method", method)
+	}
+
+	s.metrics.grpcTotal.WithLabelValues(code, method).Inc()
+	s.metrics.grpcTime.WithLabelValues(code, method).Set(float64(time.Since(t) / time.Millisecond))
+}
+
+// grpcServerStream overwrites the context of a server stream.
+type grpcServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcServerStream) Context() context.Context {
+	return s.ctx
+}
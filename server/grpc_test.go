@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Test_Server_GRPC ensures gRPC services are served either on the listen
+// address of the HTTP endpoints or on their own listen address, propagating
+// request IDs, recovering panics and tracking metrics.
+func Test_Server_GRPC(t *testing.T) {
+	testCases := []struct {
+		Separate bool
+	}{
+		// Case 1 ensures gRPC services are multiplexed with HTTP endpoints.
+		{
+			Separate: false,
+		},
+		// Case 2 ensures gRPC services are served on their own listen address.
+		{
+			Separate: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		grpcAddress := listener.Addr().String()
+		var grpcListener net.Listener
+		if tc.Separate {
+			grpcListener, err = net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			grpcAddress = grpcListener.Addr().String()
+		}
+
+		registry := prometheus.NewRegistry()
+
+		config := Config{
+			GRPCListener: grpcListener,
+			Listener:     listener,
+			Logger:       microloggertest.New(),
+			Registry:     registry,
+
+			Endpoints: []Endpoint{testNewEndpoint(t)},
+			GRPCServices: []GRPCService{
+				{
+					Desc: &healthpb.Health_ServiceDesc,
+					Impl: health.NewServer(),
+				},
+				{
+					Desc: &testPanicServiceDesc,
+					Impl: nil,
+				},
+			},
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		newServer.Boot()
+
+		conn, err := grpc.NewClient(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		{
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "test-request-id")
+
+			var header metadata.MD
+			res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+				t.Fatal("case", i+1, "expected", healthpb.HealthCheckResponse_SERVING, "got", res.GetStatus())
+			}
+			if strings.Join(header.Get("x-request-id"), "") != "test-request-id" {
+				t.Fatal("case", i+1, "expected", "test-request-id", "got", header.Get("x-request-id"))
+			}
+		}
+
+		{
+			err := conn.Invoke(context.Background(), "/test.Panic/Panic", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+			if status.Code(err) != codes.Internal {
+				t.Fatal("case", i+1, "expected", codes.Internal, "got", status.Code(err))
+			}
+		}
+
+		// HTTP endpoints are still served.
+		{
+			res, err := http.Get("http://" + listener.Addr().String() + "/test-path")
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != http.StatusOK {
+				t.Fatal("case", i+1, "expected", http.StatusOK, "got", res.StatusCode)
+			}
+		}
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		var grpcTotal float64
+		for _, f := range families {
+			if f.GetName() == "grpc_request_total" {
+				for _, m := range f.GetMetric() {
+					grpcTotal += m.GetCounter().GetValue()
+				}
+			}
+		}
+		if grpcTotal != 2 {
+			t.Fatal("case", i+1, "expected", 2, "got", grpcTotal)
+		}
+
+		conn.Close()
+		newServer.Shutdown()
+	}
+}
+
+var testPanicServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Panic",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Panic",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &healthpb.HealthCheckRequest{}
+				err := dec(in)
+				if err != nil {
+					return nil, err
+				}
+
+				info := &grpc.UnaryServerInfo{
+					Server:     srv,
+					FullMethod: "/test.Panic/Panic",
+				}
+				handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+					panic("test panic")
+				}
+
+				return interceptor(ctx, in, info, handler)
+			},
+		},
+	},
+}
+
+// Test_Server_GRPC_Stream ensures streaming gRPC calls multiplexed with HTTP
+// endpoints are not cut off by the read and write timeouts of the HTTP
+// server.
+func Test_Server_GRPC_Stream(t *testing.T) {
+	healthServer := health.NewServer()
+
+	config := Config{
+		Logger:   microloggertest.New(),
+		Registry: prometheus.NewRegistry(),
+
+		Endpoints: []Endpoint{testNewEndpoint(t)},
+		GRPCServices: []GRPCService{
+			{
+				Desc: &healthpb.Health_ServiceDesc,
+				Impl: healthServer,
+			},
+		},
+		ListenAddress: "http://127.0.0.1:8000",
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	httpServer := httptest.NewUnstartedServer(newServer.Handler())
+	httpServer.Config.Protocols = protocols
+	httpServer.Config.ReadTimeout = 100 * time.Millisecond
+	httpServer.Config.WriteTimeout = 100 * time.Millisecond
+	httpServer.Start()
+	defer httpServer.Close()
+
+	conn, err := grpc.NewClient(httpServer.Listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	defer conn.Close()
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatal("expected", healthpb.HealthCheckResponse_SERVING, "got", res.GetStatus())
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	res, err = stream.Recv()
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if res.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatal("expected", healthpb.HealthCheckResponse_NOT_SERVING, "got", res.GetStatus())
+	}
+}
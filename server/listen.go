@@ -0,0 +1,162 @@
+package server
+
+import (
+	cryptotls "crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/microkit/tls"
+)
+
+const (
+	// DefaultSocketMode is the file mode of Unix domain sockets the server
+	// listens on, in case no other mode is configured.
+	DefaultSocketMode os.FileMode = 0660
+)
+
+// ListenAddress is an address the server is listening on.
+type ListenAddress struct {
+	// Address is the address to listen on, e.g. https://0.0.0.0:8443 or
+	// unix:///run/svc.sock. It may be empty in case Listener is given.
+	Address string
+	// Listener is an optional listener accepting connections instead of
+	// listening on Address.
+	Listener net.Listener
+	// TLSCertFiles are the TLS settings used for https addresses. In case they
+	// are empty, the TLS settings of the server configuration are used.
+	TLSCertFiles tls.CertFiles
+}
+
+// listen is a listen address of a server and its state.
+type listen struct {
+	httpServer   *http.Server
+	listener     net.Listener
+	tlsCertFiles tls.CertFiles
+	tlsConfig    *cryptotls.Config
+	url          *url.URL
+}
+
+// NewListener creates a listener for the given listen address the same way
+// the server does when no listener is configured. This allows callers to
+// create listeners upfront, e.g. to hand them over to other processes.
+func NewListener(listenAddress string, socketMode os.FileMode) (net.Listener, error) {
+	listenURL, err := url.Parse(listenAddress)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%s", err.Error())
+	}
+	if socketMode == 0 {
+		socketMode = DefaultSocketMode
+	}
+
+	l, err := newListener(listenURL, socketMode)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return l, nil
+}
+
+// newListenAddressURL returns the URL of the given listen address.
+func newListenAddressURL(a ListenAddress) (*url.URL, error) {
+	if a.Address == "" && a.Listener == nil {
+		return nil, microerror.Maskf(invalidConfigError, "listen address must not be empty")
+	}
+	if a.Address == "" {
+		return newListenURL(a.Listener), nil
+	}
+
+	u, err := url.Parse(a.Address)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%s", err.Error())
+	}
+	if u.Scheme == "unix" && socketPath(u) == "" {
+		return nil, microerror.Maskf(invalidConfigError, "unix socket path must not be empty")
+	}
+
+	return u, nil
+}
+
+// newListenURL returns the URL of the given listener, which is used in case
+// the server is configured with a listener but without a listen address.
+func newListenURL(listener net.Listener) *url.URL {
+	addr := listener.Addr()
+	if addr.Network() == "unix" {
+		return &url.URL{Scheme: "unix", Path: addr.String()}
+	}
+
+	return &url.URL{Scheme: "http", Host: addr.String()}
+}
+
+// newListener creates the listener for the given listen URL. Unix domain
+// sockets are created with the given file mode. Stale sockets left behind by
+// previous processes are removed, while sockets still in use cause an error.
+func newListener(listenURL *url.URL, socketMode os.FileMode) (net.Listener, error) {
+	if listenURL.Scheme != "unix" {
+		l, err := net.Listen("tcp", listenURL.Host)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return l, nil
+	}
+
+	path := socketPath(listenURL)
+
+	err := removeStaleSocket(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = os.Chmod(path, socketMode)
+	if err != nil {
+		l.Close()
+		return nil, microerror.Mask(err)
+	}
+
+	return l, nil
+}
+
+// removeStaleSocket removes the Unix domain socket at the given path in case
+// no process accepts connections on it anymore.
+func removeStaleSocket(path string) error {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if fi.Mode()&os.ModeSocket == 0 {
+		return microerror.Maskf(invalidConfigError, "listen address %s is not a unix socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return microerror.Maskf(invalidConfigError, "unix socket %s is already in use", path)
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// socketPath returns the file path of the Unix domain socket of the given
+// listen URL. Both unix:///run/svc.sock and relative paths like
+// unix://svc.sock are supported.
+func socketPath(listenURL *url.URL) string {
+	return listenURL.Host + listenURL.Path
+}
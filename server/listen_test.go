@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/microkit/tls"
+)
+
+// Test_Server_Listen ensures the server accepts connections on Unix domain
+// sockets with the configured file mode as well as on given listeners.
+func Test_Server_Listen(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "test.sock")
+
+	// Leave a stale socket behind, which must be replaced by the server.
+	{
+		l, err := net.Listen("unix", socket)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		l.Close()
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	testCases := []struct {
+		ListenAddress    string
+		ListenSocketMode os.FileMode
+		Listener         net.Listener
+		Network          string
+		Address          string
+	}{
+		// Case 1 ensures Unix domain sockets are created with the given mode.
+		{
+			ListenAddress:    "unix://" + socket,
+			ListenSocketMode: 0600,
+			Listener:         nil,
+			Network:          "unix",
+			Address:          socket,
+		},
+		// Case 2 ensures given listeners are used.
+		{
+			ListenAddress:    "",
+			ListenSocketMode: 0,
+			Listener:         listener,
+			Network:          "tcp",
+			Address:          listener.Addr().String(),
+		},
+	}
+
+	for i, tc := range testCases {
+		config := Config{
+			Listener: tc.Listener,
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			Endpoints:        []Endpoint{testNewEndpoint(t)},
+			ListenAddress:    tc.ListenAddress,
+			ListenSocketMode: tc.ListenSocketMode,
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		newServer.Boot()
+
+		client := &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, tc.Network, tc.Address)
+				},
+			},
+		}
+
+		var body []byte
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			res, err := client.Get("http://microkit/test-path")
+			if err != nil {
+				continue
+			}
+			body, err = io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			break
+		}
+
+		if string(body) != "test-response-1" {
+			t.Fatal("case", i+1, "expected", "test-response-1", "got", string(body))
+		}
+
+		if tc.Network == "unix" {
+			fi, err := os.Stat(tc.Address)
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			if fi.Mode().Perm() != tc.ListenSocketMode {
+				t.Fatal("case", i+1, "expected", tc.ListenSocketMode, "got", fi.Mode().Perm())
+			}
+		}
+
+		newServer.Shutdown()
+	}
+
+	// The server removes its socket when shutting down.
+	_, err = os.Stat(socket)
+	if !os.IsNotExist(err) {
+		t.Fatal("expected", "socket to be removed", "got", err)
+	}
+}
+
+// Test_Server_ListenAddresses ensures the server serves the same endpoints on
+// multiple listen addresses having their own TLS settings and shuts all of
+// them down together.
+func Test_Server_ListenAddresses(t *testing.T) {
+	certs, err := tls.GenerateDevCertificates(nil)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	files, err := certs.WriteFiles(t.TempDir())
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	clientTLSConfig, err := tls.LoadTLSConfig(tls.CertFiles{RootCAs: files.RootCAs})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	httpsListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	config := Config{
+		Listener: httpListener,
+		Logger:   microloggertest.New(),
+		Registry: prometheus.NewRegistry(),
+
+		Endpoints: []Endpoint{testNewEndpoint(t)},
+		ListenAddresses: []ListenAddress{
+			{
+				Address:  "https://" + httpsListener.Addr().String(),
+				Listener: httpsListener,
+				TLSCertFiles: tls.CertFiles{
+					Cert: files.Cert,
+					Key:  files.Key,
+				},
+			},
+		},
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	newServer.Boot()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: clientTLSConfig,
+		},
+	}
+
+	urls := []string{
+		"http://" + httpListener.Addr().String() + "/test-path",
+		"https://" + httpsListener.Addr().String() + "/test-path",
+	}
+	for i, u := range urls {
+		res, err := client.Get(u)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			t.Fatal("case", i+1, "expected", http.StatusOK, "got", res.StatusCode)
+		}
+	}
+
+	newServer.Shutdown()
+	client.CloseIdleConnections()
+
+	for i, u := range urls {
+		_, err := client.Get(u)
+		if err == nil {
+			t.Fatal("case", i+1, "expected", "error", "got", nil)
+		}
+	}
+}
+
+// Test_Server_HTTP2Cleartext ensures HTTP/2 is served without TLS only in
+// case h2c is enabled.
+func Test_Server_HTTP2Cleartext(t *testing.T) {
+	testCases := []struct {
+		HTTP2Cleartext bool
+		ExpectedError  bool
+	}{
+		// Case 1 ensures h2c is served when enabled.
+		{
+			HTTP2Cleartext: true,
+			ExpectedError:  false,
+		},
+		// Case 2 ensures h2c is not served by default.
+		{
+			HTTP2Cleartext: false,
+			ExpectedError:  true,
+		},
+	}
+
+	for i, tc := range testCases {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		config := Config{
+			Listener: listener,
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			Endpoints:                 []Endpoint{testNewEndpoint(t)},
+			HTTP2Cleartext:            tc.HTTP2Cleartext,
+			HTTP2MaxConcurrentStreams: 10,
+			HTTP2MaxReadFrameSize:     1 << 16,
+			HTTP2PingInterval:         time.Minute,
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		newServer.Boot()
+
+		protocols := &http.Protocols{}
+		protocols.SetUnencryptedHTTP2(true)
+		client := &http.Client{
+			Transport: &http.Transport{
+				Protocols: protocols,
+			},
+		}
+
+		res, err := client.Get("http://" + listener.Addr().String() + "/test-path")
+		if tc.ExpectedError {
+			if err == nil {
+				t.Fatal("case", i+1, "expected", "error", "got", nil)
+			}
+		} else {
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			res.Body.Close()
+
+			if res.ProtoMajor != 2 {
+				t.Fatal("case", i+1, "expected", 2, "got", res.ProtoMajor)
+			}
+		}
+
+		client.CloseIdleConnections()
+		newServer.Shutdown()
+	}
+}
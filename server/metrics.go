@@ -1,35 +1,114 @@
 package server
 
 import (
+	"github.com/giantswarm/microerror"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var (
-	endpointTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "endpoint_total",
-			Help: "Number of times we have execute the HTTP handler of an endpoint.",
-		},
-		[]string{"code", "method", "name"},
-	)
-	endpointTime = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "endpoint_milliseconds",
-			Help: "Time taken to execute the HTTP handler of an endpoint, in milliseconds.",
-		},
-		[]string{"code", "method", "name"},
-	)
-	errorTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "error_total",
-			Help: "Number of times we have seen a specific error within a specific error domain.",
-		},
-		[]string{},
-	)
-)
+// defaultMetrics are the metrics registered to the default prometheus
+// registry. They are used by all servers not configuring their own registry.
+var defaultMetrics = newMetrics()
 
 func init() {
-	prometheus.MustRegister(endpointTotal)
-	prometheus.MustRegister(endpointTime)
-	prometheus.MustRegister(errorTotal)
+	err := defaultMetrics.register(prometheus.DefaultRegisterer)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// metrics holds the collectors the server uses to instrument endpoints.
+type metrics struct {
+	endpointBytes  *prometheus.CounterVec
+	endpointTotal  *prometheus.CounterVec
+	endpointTime   *prometheus.GaugeVec
+	errorTotal     *prometheus.CounterVec
+	grpcTotal      *prometheus.CounterVec
+	grpcTime       *prometheus.GaugeVec
+	websocketOpen  *prometheus.GaugeVec
+	websocketTotal *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		endpointBytes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "endpoint_response_bytes_total",
+				Help: "Number of bytes written to the response bodies of an endpoint.",
+			},
+			[]string{"code", "method", "name"},
+		),
+		endpointTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "endpoint_total",
+				Help: "Number of times we have execute the HTTP handler of an endpoint.",
+			},
+			[]string{"code", "method", "name"},
+		),
+		endpointTime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "endpoint_milliseconds",
+				Help: "Time taken to execute the HTTP handler of an endpoint, in milliseconds.",
+			},
+			[]string{"code", "method", "name"},
+		),
+		errorTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "error_total",
+				Help: "Number of times we have seen a specific error within a specific error domain.",
+			},
+			[]string{},
+		),
+		grpcTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_request_total",
+				Help: "Number of times we have executed the handler of a gRPC method.",
+			},
+			[]string{"code", "method"},
+		),
+		grpcTime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "grpc_request_milliseconds",
+				Help: "Time taken to execute the handler of a gRPC method, in milliseconds.",
+			},
+			[]string{"code", "method"},
+		),
+		websocketOpen: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "websocket_connections",
+				Help: "Number of currently open WebSocket connections of an endpoint.",
+			},
+			[]string{"name"},
+		),
+		websocketTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "websocket_connection_total",
+				Help: "Number of times we have accepted a WebSocket connection of an endpoint.",
+			},
+			[]string{"name"},
+		),
+	}
+
+	return m
+}
+
+func (m *metrics) register(r prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		m.endpointBytes,
+		m.endpointTotal,
+		m.endpointTime,
+		m.errorTotal,
+		m.grpcTotal,
+		m.grpcTime,
+		m.websocketOpen,
+		m.websocketTotal,
+	}
+
+	for _, c := range collectors {
+		err := r.Register(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
 }
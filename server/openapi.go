@@ -0,0 +1,221 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/microkit/openapi"
+)
+
+const (
+	// DefaultOpenAPIVersion is the version of the API described by OpenAPI
+	// documents in case no other version is configured.
+	DefaultOpenAPIVersion = "0.0.0"
+)
+
+// openAPIErrorSchema is the name of the schema describing error bodies.
+const openAPIErrorSchema = "Error"
+
+var pathVariableNamePattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// EndpointDescription describes an endpoint in the OpenAPI document of the
+// server, see DescribeEndpoint.
+type EndpointDescription struct {
+	// Deprecated marks the endpoint as deprecated.
+	Deprecated bool
+	// Description is a verbose explanation of the endpoint.
+	Description string
+	// Errors are the errors the endpoint responds with next to the errors of
+	// the server itself.
+	Errors []EndpointError
+	// Request is a value of the type the endpoint decodes requests into, e.g.
+	// CreateUserRequest{}. Path variables, query parameters and headers are
+	// described by the struct tags of the binding package. Its remaining
+	// fields describe the JSON request body. It is nil for endpoints not
+	// reading requests.
+	Request interface{}
+	// Response is a value of the type the endpoint encodes as JSON response
+	// body. It is nil for endpoints without response body.
+	Response interface{}
+	// StatusCode is the HTTP status code of successful responses. It defaults
+	// to 200.
+	StatusCode int
+	// Summary is a short summary of what the endpoint does.
+	Summary string
+	// Tags group endpoints in the OpenAPI document.
+	Tags []string
+}
+
+// EndpointError describes an error response of an endpoint.
+type EndpointError struct {
+	// Code is the microkit error code of the error response, e.g.
+	// CodeResourceNotFound.
+	Code string
+	// Description explains when the error occurs.
+	Description string
+	// StatusCode is the HTTP status code of the error response.
+	StatusCode int
+}
+
+// NewOpenAPIDocument generates the OpenAPI document describing the given
+// endpoints. Endpoints implementing DescribeEndpoint are described in detail,
+// while other endpoints are only described by their method, path and name.
+func NewOpenAPIDocument(info openapi.Info, endpoints []Endpoint) (*openapi.Document, error) {
+	var err error
+
+	var generator openapi.Generator
+	{
+		c := openapi.Config{
+			Info: info,
+		}
+
+		generator, err = openapi.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	generator.Document().Components.Schemas[openAPIErrorSchema] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"code":  {Type: "string"},
+			"error": {Type: "string"},
+			"from":  {Type: "string"},
+		},
+		Required: []string{"code", "error", "from"},
+	}
+
+	for _, e := range endpoints {
+		err := generator.AddOperation(e.Method(), e.Path(), newOpenAPIOperation(generator, e))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return generator.Document(), nil
+}
+
+// newOpenAPIOperation returns the OpenAPI operation describing the given
+// endpoint.
+func newOpenAPIOperation(generator openapi.Generator, e Endpoint) *openapi.Operation {
+	var description EndpointDescription
+	if de, ok := e.(DescribeEndpoint); ok {
+		description = de.Describe()
+	}
+	if description.StatusCode == 0 {
+		description.StatusCode = http.StatusOK
+	}
+
+	operation := &openapi.Operation{
+		Deprecated:  description.Deprecated,
+		Description: description.Description,
+		OperationID: e.Name(),
+		Responses:   map[string]*openapi.Response{},
+		Summary:     description.Summary,
+		Tags:        description.Tags,
+	}
+
+	requestType := reflect.TypeOf(description.Request)
+	operation.Parameters = generator.Parameters(requestType)
+
+	// Path variables of endpoints not describing them are still documented,
+	// since OpenAPI requires every path variable to be described.
+	for _, m := range pathVariableNamePattern.FindAllStringSubmatch(e.Path(), -1) {
+		if !hasOpenAPIParameter(operation.Parameters, "path", m[1]) {
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				In:       "path",
+				Name:     m[1],
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+	}
+
+	switch e.Method() {
+	case http.MethodPatch, http.MethodPost, http.MethodPut:
+		schema := generator.RequestBody(requestType)
+		if schema != nil {
+			operation.RequestBody = &openapi.RequestBody{
+				Content:  newOpenAPIContent(schema),
+				Required: true,
+			}
+		}
+	}
+
+	{
+		response := &openapi.Response{
+			Description: http.StatusText(description.StatusCode),
+		}
+		if description.Response != nil && description.StatusCode != http.StatusNoContent {
+			response.Content = newOpenAPIContent(generator.Schema(reflect.TypeOf(description.Response)))
+		}
+		operation.Responses[strconv.Itoa(description.StatusCode)] = response
+	}
+
+	// Errors having the same status code are described by the same response.
+	errors := map[int][]string{}
+	for _, e := range description.Errors {
+		d := e.Code
+		if e.Description != "" {
+			d += ": " + e.Description
+		}
+		errors[e.StatusCode] = append(errors[e.StatusCode], d)
+	}
+	for statusCode, descriptions := range errors {
+		sort.Strings(descriptions)
+		operation.Responses[strconv.Itoa(statusCode)] = &openapi.Response{
+			Content:     newOpenAPIContent(&openapi.Schema{Ref: "#/components/schemas/" + openAPIErrorSchema}),
+			Description: strings.Join(descriptions, "; "),
+		}
+	}
+
+	operation.Responses["default"] = &openapi.Response{
+		Content:     newOpenAPIContent(&openapi.Schema{Ref: "#/components/schemas/" + openAPIErrorSchema}),
+		Description: "Error response.",
+	}
+
+	return operation
+}
+
+func hasOpenAPIParameter(parameters []openapi.Parameter, in, name string) bool {
+	for _, p := range parameters {
+		if p.In == in && p.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newOpenAPIContent(schema *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{
+		ContentTypeJSON: {
+			Schema: schema,
+		},
+	}
+}
+
+// newOpenAPIHandler returns the HTTP handler serving the OpenAPI document of
+// the server as YAML in case it is accepted by the request, or as JSON
+// otherwise.
+func (s *server) newOpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		codec := negotiateCodec(r)
+		if codec == nil || codec.ContentType() != ContentTypeYAML {
+			codec, _ = CodecForContentType(ContentTypeJSON)
+		}
+
+		w.Header().Set("Content-Type", newContentTypeHeader(codec))
+		err := codec.Encode(w, s.openAPIDocument)
+		if err != nil {
+			s.logger.Log("level", "error", "message", "failed to encode OpenAPI document", "stack", fmt.Sprintf("%#v", err))
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/giantswarm/microkit/openapi"
+)
+
+// Test_Server_OpenAPI ensures the OpenAPI document describing the endpoints is
+// served as JSON or YAML.
+func Test_Server_OpenAPI(t *testing.T) {
+	type testRequest struct {
+		ID   string `path:"id"`
+		Name string `json:"name"`
+	}
+	type testResponse struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	e1 := NewEndpoint("update-user", http.MethodPut, "/users/{id}", func(ctx context.Context, request testRequest) (*testResponse, error) {
+		return &testResponse{ID: request.ID, Name: request.Name}, nil
+	})
+	e1.WithDescription(EndpointDescription{
+		Errors: []EndpointError{
+			{Code: CodeResourceNotFound, Description: "user does not exist", StatusCode: http.StatusNotFound},
+		},
+		Summary: "Update a user.",
+	})
+	e2 := testNewEndpoint(t)
+
+	config := Config{
+		Logger:   microloggertest.New(),
+		Registry: prometheus.NewRegistry(),
+
+		Endpoints:     []Endpoint{e1, e2},
+		ListenAddress: "http://127.0.0.1:8000",
+		OpenAPIInfo:   openapi.Info{Title: "test-service"},
+		OpenAPIPath:   "/openapi",
+		ServiceName:   "test-service",
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	var document openapi.Document
+	{
+		r := httptest.NewRequest(http.MethodGet, "/openapi", nil)
+		w := httptest.NewRecorder()
+
+		newServer.Handler().ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatal("expected", http.StatusOK, "got", w.Code)
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &document)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	if document.Info.Version != DefaultOpenAPIVersion {
+		t.Fatal("expected", DefaultOpenAPIVersion, "got", document.Info.Version)
+	}
+
+	operation := document.Paths["/users/{id}"]["put"]
+	if operation == nil {
+		t.Fatal("expected", "operation", "got", nil)
+	}
+	if operation.Summary != "Update a user." {
+		t.Fatal("expected", "Update a user.", "got", operation.Summary)
+	}
+	if len(operation.Parameters) != 1 || operation.Parameters[0].Name != "id" {
+		t.Fatal("expected", "id", "got", operation.Parameters)
+	}
+	if operation.RequestBody == nil || operation.RequestBody.Content[ContentTypeJSON].Schema.Properties["name"] == nil {
+		t.Fatal("expected", "request body", "got", operation.RequestBody)
+	}
+	if operation.Responses["200"].Content[ContentTypeJSON].Schema.Ref != "#/components/schemas/testResponse" {
+		t.Fatal("expected", "#/components/schemas/testResponse", "got", operation.Responses["200"].Content[ContentTypeJSON].Schema.Ref)
+	}
+	if operation.Responses["404"].Description != "RESOURCE_NOT_FOUND: user does not exist" {
+		t.Fatal("expected", "RESOURCE_NOT_FOUND: user does not exist", "got", operation.Responses["404"].Description)
+	}
+	if document.Paths["/test-path"]["get"].OperationID != "test-endpoint" {
+		t.Fatal("expected", "test-endpoint", "got", document.Paths["/test-path"]["get"])
+	}
+
+	{
+		r := httptest.NewRequest(http.MethodGet, "/openapi", nil)
+		r.Header.Set("Accept", ContentTypeYAML)
+		w := httptest.NewRecorder()
+
+		newServer.Handler().ServeHTTP(w, r)
+
+		if w.Header().Get("Content-Type") != ContentTypeYAML {
+			t.Fatal("expected", ContentTypeYAML, "got", w.Header().Get("Content-Type"))
+		}
+		var document openapi.Document
+		err := yaml.Unmarshal(w.Body.Bytes(), &document)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		if document.Info.Title != "test-service" {
+			t.Fatal("expected", "test-service", "got", document.Info.Title)
+		}
+	}
+}
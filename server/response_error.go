@@ -1,7 +1,15 @@
 package server
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
 	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/microkit/binding"
+	"github.com/giantswarm/microkit/validator"
 )
 
 // ResponseErrorConfig represents the configuration used to create a new
@@ -71,3 +79,93 @@ func (e *responseError) SetMessage(message string) {
 func (e *responseError) Underlying() error {
 	return e.underlying
 }
+
+// builtinError describes errors of the server itself, which have their own
+// microkit error code and HTTP status code.
+type builtinError struct {
+	Code       string
+	Matcher    func(err error) bool
+	StatusCode int
+}
+
+var builtinErrors = []builtinError{
+	{
+		Code:       CodeInvalidInput,
+		Matcher:    IsInvalidRequestBody,
+		StatusCode: http.StatusBadRequest,
+	},
+	{
+		Code:       CodeInvalidInput,
+		Matcher:    binding.IsInvalidInputError,
+		StatusCode: http.StatusBadRequest,
+	},
+	{
+		Code:       CodeInvalidInput,
+		Matcher:    validator.IsSchemaError,
+		StatusCode: http.StatusBadRequest,
+	},
+	{
+		Code:       CodeNotAcceptable,
+		Matcher:    IsNotAcceptable,
+		StatusCode: http.StatusNotAcceptable,
+	},
+	{
+		Code:       CodePreconditionFailed,
+		Matcher:    IsPreconditionFailed,
+		StatusCode: http.StatusPreconditionFailed,
+	},
+	{
+		Code:       CodeRequestBodyTooLarge,
+		Matcher:    IsRequestBodyTooLarge,
+		StatusCode: http.StatusRequestEntityTooLarge,
+	},
+	{
+		Code:       CodeUnsupportedMediaType,
+		Matcher:    IsUnsupportedMediaType,
+		StatusCode: http.StatusUnsupportedMediaType,
+	},
+}
+
+// newBuiltinError returns the description of the given error in case it is
+// an error of the server itself.
+func newBuiltinError(err error) (builtinError, bool) {
+	for _, b := range builtinErrors {
+		if b.Matcher(err) {
+			return b, true
+		}
+	}
+
+	return builtinError{}, false
+}
+
+// errorBody is the body of error responses.
+type errorBody struct {
+	Code  string `json:"code" yaml:"code"`
+	Error string `json:"error" yaml:"error"`
+	From  string `json:"from" yaml:"from"`
+}
+
+// errorCodecs caches per content type whether its codec is able to encode
+// error bodies, so that it is only checked once. Entries are removed when a
+// codec is registered for their content type, see RegisterCodec.
+var errorCodecs sync.Map
+
+// newErrorCodec returns the codec used to encode error bodies, which is the
+// codec of the given context in case it is able to encode them. Otherwise it
+// is JSON.
+func newErrorCodec(ctx context.Context) Codec {
+	codec, ok := CodecFromContext(ctx)
+	if ok {
+		encodes, cached := errorCodecs.Load(codec.ContentType())
+		if !cached {
+			encodes = codec.Encode(io.Discard, errorBody{}) == nil
+			errorCodecs.Store(codec.ContentType(), encodes)
+		}
+		if encodes.(bool) {
+			return codec
+		}
+	}
+
+	codec, _ = CodecForContentType(ContentTypeJSON)
+	return codec
+}
@@ -1,37 +1,55 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"io"
+	"net"
 	"net/http"
+	"sync"
 
 	"github.com/giantswarm/microerror"
 )
 
+// bodyBufferPool holds the buffers used to capture response bodies, so that
+// endpoints capturing their response bodies do not allocate new buffers for
+// every request.
+var bodyBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
 // ResponseWriterConfig represents the configuration used to create a new
 // response writer.
 type ResponseWriterConfig struct {
 	// Settings.
-	BodyBuffer     *bytes.Buffer
-	ResponseWriter http.ResponseWriter
-	StatusCode     int
+	BodyBuffer *bytes.Buffer
+	// BodyBufferMaxSize is the maximum number of bytes captured in BodyBuffer.
+	// Bodies exceeding it are truncated, which is expressed by
+	// ResponseWriter.BodyTruncated. Bodies are captured completely when zero.
+	BodyBufferMaxSize int
+	ResponseWriter    http.ResponseWriter
+	StatusCode        int
 }
 
 // DefaultResponseWriterConfig provides a default configuration to create a new
-// response writer by best effort.
+// response writer by best effort. Response bodies are not captured by default.
 func DefaultResponseWriterConfig() ResponseWriterConfig {
 	return ResponseWriterConfig{
 		// Settings.
-		BodyBuffer:     &bytes.Buffer{},
-		ResponseWriter: nil,
-		StatusCode:     http.StatusOK,
+		BodyBuffer:        nil,
+		BodyBufferMaxSize: 0,
+		ResponseWriter:    nil,
+		StatusCode:        http.StatusOK,
 	}
 }
 
 // New creates a new configured response writer.
 func NewResponseWriter(config ResponseWriterConfig) (ResponseWriter, error) {
 	// Settings.
-	if config.BodyBuffer == nil {
-		return nil, microerror.Maskf(invalidConfigError, "body buffer must not be empty")
+	if config.BodyBufferMaxSize < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "body buffer max size must not be negative")
 	}
 	if config.ResponseWriter == nil {
 		return nil, microerror.Maskf(invalidConfigError, "response writer must not be empty")
@@ -42,12 +60,15 @@ func NewResponseWriter(config ResponseWriterConfig) (ResponseWriter, error) {
 
 	newResponseWriter := &responseWriter{
 		// Internals.
-		hasWritten: false,
+		bodyTruncated: false,
+		bytesWritten:  0,
+		hasWritten:    false,
 
 		// Settings.
-		bodyBuffer:     config.BodyBuffer,
-		responseWriter: config.ResponseWriter,
-		statusCode:     config.StatusCode,
+		bodyBuffer:        config.BodyBuffer,
+		bodyBufferMaxSize: config.BodyBufferMaxSize,
+		responseWriter:    config.ResponseWriter,
+		statusCode:        config.StatusCode,
 	}
 
 	return newResponseWriter, nil
@@ -55,18 +76,48 @@ func NewResponseWriter(config ResponseWriterConfig) (ResponseWriter, error) {
 
 type responseWriter struct {
 	// Internals.
-	hasWritten bool
+	bodyTruncated bool
+	bytesWritten  int64
+	hasWritten    bool
 
 	// Settings.
-	bodyBuffer     *bytes.Buffer
-	responseWriter http.ResponseWriter
-	statusCode     int
+	bodyBuffer        *bytes.Buffer
+	bodyBufferMaxSize int
+	responseWriter    http.ResponseWriter
+	statusCode        int
 }
 
 func (rw *responseWriter) BodyBuffer() *bytes.Buffer {
+	// Error encoders used to rely on the body buffer being given, which is
+	// why an empty buffer is returned in case bodies are not captured.
+	if rw.bodyBuffer == nil {
+		return &bytes.Buffer{}
+	}
+
 	return rw.bodyBuffer
 }
 
+func (rw *responseWriter) BodyTruncated() bool {
+	return rw.bodyTruncated
+}
+
+func (rw *responseWriter) BytesWritten() int64 {
+	return rw.bytesWritten
+}
+
+func (rw *responseWriter) Captured() bool {
+	return rw.bodyBuffer != nil
+}
+
+// Flush passes through to the underlying response writer in case it
+// implements http.Flusher.
+func (rw *responseWriter) Flush() {
+	f, ok := rw.responseWriter.(http.Flusher)
+	if ok {
+		f.Flush()
+	}
+}
+
 func (rw *responseWriter) HasWritten() bool {
 	return rw.hasWritten
 }
@@ -75,6 +126,40 @@ func (rw *responseWriter) Header() http.Header {
 	return rw.responseWriter.Header()
 }
 
+// Hijack passes through to the underlying response writer in case it
+// implements http.Hijacker.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, microerror.Mask(http.ErrNotSupported)
+	}
+
+	return h.Hijack()
+}
+
+// ReadFrom passes through to the underlying response writer in case it
+// implements io.ReaderFrom, which allows e.g. files to be sent using
+// sendfile. Bytes are still captured in the body buffer, if any.
+func (rw *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	rw.hasWritten = true
+
+	if rw.bodyBuffer != nil {
+		r = io.TeeReader(r, writerFunc(rw.capture))
+	}
+
+	var n int64
+	var err error
+	rf, ok := rw.responseWriter.(io.ReaderFrom)
+	if ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(rw.responseWriter, r)
+	}
+	rw.bytesWritten += n
+
+	return n, err
+}
+
 func (rw *responseWriter) StatusCode() int {
 	return rw.statusCode
 }
@@ -82,15 +167,55 @@ func (rw *responseWriter) StatusCode() int {
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	rw.hasWritten = true
 
-	_, err := rw.bodyBuffer.Write(b)
-	if err != nil {
-		return 0, microerror.Mask(err)
+	if rw.bodyBuffer != nil {
+		_, err := rw.capture(b)
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
 	}
 
-	return rw.responseWriter.Write(b)
+	n, err := rw.responseWriter.Write(b)
+	rw.bytesWritten += int64(n)
+
+	return n, err
 }
 
 func (rw *responseWriter) WriteHeader(c int) {
 	rw.responseWriter.WriteHeader(c)
 	rw.statusCode = c
 }
+
+// Unwrap returns the underlying response writer, which is used by
+// http.ResponseController to access e.g. deadlines of the connection.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.responseWriter
+}
+
+// capture writes the given bytes to the body buffer as long as its max size
+// is not exceeded. It always reports the given bytes as written, so that
+// truncating the captured body does not interrupt the response.
+func (rw *responseWriter) capture(b []byte) (int, error) {
+	n := len(b)
+
+	if rw.bodyBufferMaxSize > 0 {
+		free := rw.bodyBufferMaxSize - rw.bodyBuffer.Len()
+		if free < len(b) {
+			rw.bodyTruncated = true
+			b = b[:max(free, 0)]
+		}
+	}
+
+	_, err := rw.bodyBuffer.Write(b)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return n, nil
+}
+
+// writerFunc is an adapter to use functions as io.Writer.
+type writerFunc func(b []byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) {
+	return f(b)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test_ResponseWriter_Capture ensures response bodies are only captured when
+// a body buffer is given, truncated at its max size and counted in any case.
+func Test_ResponseWriter_Capture(t *testing.T) {
+	testCases := []struct {
+		BodyBuffer            *bytes.Buffer
+		BodyBufferMaxSize     int
+		ExpectedBody          string
+		ExpectedBodyTruncated bool
+		ExpectedCaptured      bool
+	}{
+		// Case 1 ensures bodies are not captured by default, while the body
+		// buffer is still given.
+		{
+			BodyBuffer:            nil,
+			BodyBufferMaxSize:     0,
+			ExpectedBody:          "",
+			ExpectedBodyTruncated: false,
+			ExpectedCaptured:      false,
+		},
+		// Case 2 ensures bodies are captured completely without max size.
+		{
+			BodyBuffer:            &bytes.Buffer{},
+			BodyBufferMaxSize:     0,
+			ExpectedBody:          "test-body-test-body",
+			ExpectedBodyTruncated: false,
+			ExpectedCaptured:      true,
+		},
+		// Case 3 ensures bodies exceeding the max size are truncated.
+		{
+			BodyBuffer:            &bytes.Buffer{},
+			BodyBufferMaxSize:     12,
+			ExpectedBody:          "test-body-te",
+			ExpectedBodyTruncated: true,
+			ExpectedCaptured:      true,
+		},
+	}
+
+	for i, tc := range testCases {
+		w := httptest.NewRecorder()
+
+		config := DefaultResponseWriterConfig()
+		config.BodyBuffer = tc.BodyBuffer
+		config.BodyBufferMaxSize = tc.BodyBufferMaxSize
+		config.ResponseWriter = w
+		rw, err := NewResponseWriter(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		_, err = rw.Write([]byte("test-body-"))
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		_, err = io.Copy(rw, strings.NewReader("test-body"))
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		if w.Body.String() != "test-body-test-body" {
+			t.Fatal("case", i+1, "expected", "test-body-test-body", "got", w.Body.String())
+		}
+		if rw.BytesWritten() != 19 {
+			t.Fatal("case", i+1, "expected", 19, "got", rw.BytesWritten())
+		}
+		if rw.BodyBuffer().String() != tc.ExpectedBody {
+			t.Fatal("case", i+1, "expected", tc.ExpectedBody, "got", rw.BodyBuffer().String())
+		}
+		if rw.Captured() != tc.ExpectedCaptured {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCaptured, "got", rw.Captured())
+		}
+		if rw.BodyTruncated() != tc.ExpectedBodyTruncated {
+			t.Fatal("case", i+1, "expected", tc.ExpectedBodyTruncated, "got", rw.BodyTruncated())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	// RouteNameMetrics is the name of the route serving the prometheus metrics
+	// of the server.
+	RouteNameMetrics = "metrics"
+	// RouteNameOpenAPI is the name of the route serving the OpenAPI document of
+	// the server, see Config.OpenAPIPath.
+	RouteNameOpenAPI = "openapi"
+	// RouteNameRoutes is the name of the route listing the routes of the
+	// server, see Config.RoutesPath.
+	RouteNameRoutes = "routes"
+)
+
+// Route describes a route registered by the server.
+type Route struct {
+	// Instrumented expresses whether requests of the route are tracked by the
+	// prometheus metrics of the server.
+	Instrumented bool `json:"instrumented" yaml:"instrumented"`
+	// Method is the HTTP method of the route. It is empty for routes matching
+	// every method.
+	Method string `json:"method" yaml:"method"`
+	// Middlewares is the number of middlewares the endpoint of the route
+	// configures.
+	Middlewares int `json:"middlewares" yaml:"middlewares"`
+	// Name is the name of the endpoint of the route.
+	Name string `json:"name" yaml:"name"`
+	// Path is the HTTP request URL path of the route.
+	Path string `json:"path" yaml:"path"`
+}
+
+// NewRoutes returns the routes a server created using the given configuration
+// registers, in the order they are matched. It does not create the server,
+// which allows to inspect the routes of a microservice without running it.
+func NewRoutes(config Config) []Route {
+	var routes []Route
+
+	for _, e := range config.Endpoints {
+		routes = append(routes, Route{
+			Instrumented: true,
+			Method:       e.Method(),
+			Middlewares:  len(e.Middlewares()),
+			Name:         e.Name(),
+			Path:         e.Path(),
+		})
+	}
+
+	for _, e := range config.WebSocketEndpoints {
+		routes = append(routes, Route{
+			Instrumented: true,
+			Method:       http.MethodGet,
+			Name:         e.Name(),
+			Path:         e.Path(),
+		})
+	}
+
+	if config.OpenAPIPath != "" {
+		routes = append(routes, Route{
+			Method: http.MethodGet,
+			Name:   RouteNameOpenAPI,
+			Path:   config.OpenAPIPath,
+		})
+	}
+
+	if config.RoutesPath != "" {
+		routes = append(routes, Route{
+			Method: http.MethodGet,
+			Name:   RouteNameRoutes,
+			Path:   config.RoutesPath,
+		})
+	}
+
+	// The metrics are only served next to the endpoints in case they are not
+	// served on their own address.
+	if config.ListenMetricsAddress == "" && config.MetricsListener == nil {
+		routes = append(routes, Route{
+			Name: RouteNameMetrics,
+			Path: "/metrics",
+		})
+	}
+
+	return routes
+}
+
+// newRoutesHandler returns the HTTP handler listing the routes of the server
+// as YAML in case it is accepted by the request, or as JSON otherwise.
+func (s *server) newRoutesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		codec := negotiateCodec(r)
+		if codec == nil || codec.ContentType() != ContentTypeYAML {
+			codec, _ = CodecForContentType(ContentTypeJSON)
+		}
+
+		w.Header().Set("Content-Type", newContentTypeHeader(codec))
+		err := codec.Encode(w, s.routes)
+		if err != nil {
+			s.logger.Log("level", "error", "message", "failed to encode routes", "stack", fmt.Sprintf("%#v", err))
+		}
+	})
+}
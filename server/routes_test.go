@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Test_Server_Routes ensures the routes of the server are listed at the
+// configured path.
+func Test_Server_Routes(t *testing.T) {
+	middleware := func(next kitendpoint.Endpoint) kitendpoint.Endpoint {
+		return next
+	}
+
+	e1 := NewEndpoint("get-user", http.MethodGet, "/users/{id}", func(ctx context.Context, request struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+	e1.WithMiddlewares(middleware, middleware)
+	e2 := testNewEndpoint(t)
+
+	config := Config{
+		Logger:   microloggertest.New(),
+		Registry: prometheus.NewRegistry(),
+
+		Endpoints:          []Endpoint{e1, e2},
+		ListenAddress:      "http://127.0.0.1:8000",
+		OpenAPIPath:        "/openapi",
+		RoutesPath:         "/debug/routes",
+		ServiceName:        "test-service",
+		WebSocketEndpoints: []WebSocketEndpoint{&testWebSocketEndpoint{}},
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/debug/routes", nil)
+	w := httptest.NewRecorder()
+
+	newServer.Handler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatal("expected", http.StatusOK, "got", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatal("expected", "application/json; charset=utf-8", "got", w.Header().Get("Content-Type"))
+	}
+
+	var routes []Route
+	err = json.Unmarshal(w.Body.Bytes(), &routes)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	expected := []Route{
+		{Instrumented: true, Method: http.MethodGet, Middlewares: 2, Name: "get-user", Path: "/users/{id}"},
+		{Instrumented: true, Method: http.MethodGet, Middlewares: 0, Name: "test-endpoint", Path: "/test-path"},
+		{Instrumented: true, Method: http.MethodGet, Middlewares: 0, Name: "test-websocket", Path: "/test-websocket"},
+		{Instrumented: false, Method: http.MethodGet, Middlewares: 0, Name: RouteNameOpenAPI, Path: "/openapi"},
+		{Instrumented: false, Method: http.MethodGet, Middlewares: 0, Name: RouteNameRoutes, Path: "/debug/routes"},
+		{Instrumented: false, Method: "", Middlewares: 0, Name: RouteNameMetrics, Path: "/metrics"},
+	}
+	if !reflect.DeepEqual(expected, routes) {
+		t.Fatal("expected", expected, "got", routes)
+	}
+}
+
+// Test_Server_Routes_Disabled ensures the routes of the server are not listed
+// unless configured.
+func Test_Server_Routes_Disabled(t *testing.T) {
+	config := Config{
+		Logger:   microloggertest.New(),
+		Registry: prometheus.NewRegistry(),
+
+		Endpoints:            []Endpoint{testNewEndpoint(t)},
+		ListenAddress:        "http://127.0.0.1:8000",
+		ListenMetricsAddress: "http://127.0.0.1:8001",
+		ServiceName:          "test-service",
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/debug/routes", nil)
+	w := httptest.NewRecorder()
+
+	newServer.Handler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatal("expected", http.StatusNotFound, "got", w.Code)
+	}
+
+	routes := NewRoutes(config)
+	if len(routes) != 1 || routes[0].Name != "test-endpoint" {
+		t.Fatal("expected", 1, "got", routes)
+	}
+}
@@ -3,12 +3,16 @@
 package server
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	cryptotls "crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	_ "net/http/pprof" //nolint:gosec
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,9 +23,13 @@ import (
 	kitendpoint "github.com/go-kit/kit/endpoint"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 
+	"github.com/giantswarm/microkit/openapi"
 	"github.com/giantswarm/microkit/tls"
 )
 
@@ -32,12 +40,57 @@ type Config struct {
 	// implement error response writing them self. This is done by the server
 	// itself. Duplicated response writing will lead to runtime panics.
 	ErrorEncoder kithttp.ErrorEncoder
+	// GRPCListener is an optional listener the gRPC services accept connections
+	// on instead of listening on GRPCListenAddress itself.
+	GRPCListener net.Listener
 	// Logger is the logger used to print log messages.
 	Logger micrologger.Logger
+	// Listener is an optional listener the server accepts connections on
+	// instead of listening on ListenAddress itself. It is closed when the
+	// server shuts down.
+	Listener net.Listener
+	// MetricsListener is an optional listener the metrics server accepts
+	// connections on instead of listening on ListenMetricsAddress itself. When
+	// given, the /metrics endpoint is not served by the main server.
+	MetricsListener net.Listener
+	// Registry is an optional prometheus registry the server registers its
+	// metrics to and exposes via the /metrics endpoint. It defaults to the
+	// default prometheus registry. Using a dedicated registry isolates the
+	// metrics of multiple servers within the same process, e.g. in tests.
+	Registry *prometheus.Registry
 	// Router is a HTTP handler for the server. The returned router will have all
 	// endpoints registered that are listed in the endpoint collection.
 	Router *mux.Router
 
+	// Compression enables gzip and zstd compression of endpoint responses,
+	// negotiated via the Accept-Encoding header of requests. Endpoints can opt
+	// out via CompressEndpoint. Responses of stream endpoints are not
+	// compressed.
+	Compression bool
+	// CompressionContentTypes are the content types of responses being
+	// compressed. Entries ending with a slash match all subtypes. It defaults
+	// to DefaultCompressionContentTypes.
+	CompressionContentTypes []string
+	// CompressionMaxRequestBodySize is the maximum size in bytes of
+	// decompressed request bodies, which protects endpoints from small
+	// compressed bodies expanding without bound. Requests exceeding it are
+	// responded with HTTP status 413. It defaults to
+	// DefaultCompressionMaxRequestBodySize.
+	CompressionMaxRequestBodySize int64
+	// CompressionMinSize is the minimum size in bytes of response bodies being
+	// compressed. It defaults to DefaultCompressionMinSize.
+	CompressionMinSize int
+	// CompressionRequestBodies enables the decompression of gzip encoded
+	// request bodies of endpoints.
+	CompressionRequestBodies bool
+	// ETags enables entity tags and conditional requests for endpoints. GET
+	// and HEAD responses carry an ETag header, which is supplied by endpoints
+	// implementing ETagEndpoint or computed from the response body.
+	// Requests whose If-None-Match or If-Modified-Since header match are
+	// responded with HTTP status 304. Mutating endpoints check If-Match
+	// preconditions via CheckIfMatch. Responses of stream endpoints are not
+	// affected.
+	ETags bool
 	// EnableDebugServer boolean flag to enable debug server on
 	// http://127.0.0.1:6060/debug. This server is primarily used to expose
 	// net/http/pprof.Handler.
@@ -45,32 +98,117 @@ type Config struct {
 	// Endpoints is the server's configured list of endpoints. These are the
 	// custom endpoints configured by the client.
 	Endpoints []Endpoint
+	// GRPCListenAddress is an optional address the gRPC services are served on.
+	// https:// addresses use the TLS settings of the server configuration. When
+	// empty, gRPC services are served on the listen addresses of the HTTP
+	// endpoints, where gRPC requests are identified by their content type.
+	// This requires HTTP/2, which is why h2c is enabled for http:// listen
+	// addresses in this case.
+	GRPCListenAddress string
+	// GRPCServices are the gRPC services served next to the endpoints.
+	GRPCServices []GRPCService
 	// HandlerWrapper is a wrapper provided to interact with the request on its
 	// roots.
 	HandlerWrapper func(h http.Handler) http.Handler
-	// ListenAddress is the address the server is listening on.
+	// HTTP2Cleartext enables HTTP/2 without TLS, also known as h2c, on http://
+	// and unix:// listen addresses. Clients must use prior knowledge, since
+	// upgrades from HTTP/1.1 are not supported.
+	HTTP2Cleartext bool
+	// HTTP2MaxConcurrentStreams is the maximum number of concurrent streams per
+	// HTTP/2 connection. Zero uses the default of the standard library, which
+	// is at least 100.
+	HTTP2MaxConcurrentStreams int
+	// HTTP2MaxReadFrameSize is the largest HTTP/2 frame in bytes the server is
+	// willing to read. It must be between 16KiB and 16MiB. Zero uses the default
+	// of the standard library.
+	HTTP2MaxReadFrameSize int
+	// HTTP2PingInterval is the interval after which idle HTTP/2 connections are
+	// checked using ping frames. Zero disables health checks.
+	HTTP2PingInterval time.Duration
+	// HTTP2PingTimeout is the timeout after which HTTP/2 connections are closed
+	// in case ping frames are not answered. It defaults to 15 seconds.
+	HTTP2PingTimeout time.Duration
+	// ListenAddress is the address the server is listening on. Next to http://
+	// and https:// addresses, Unix domain sockets like unix:///run/svc.sock are
+	// supported. It may be empty in case Listener or ListenAddresses are given.
 	ListenAddress string
+	// ListenAddresses are additional addresses the server is listening on next
+	// to ListenAddress, each having its own TLS settings. All of them serve the
+	// same endpoints.
+	ListenAddresses []ListenAddress
 	// ListenMetricsAddress is an optional address where the server will expose the
 	// `/metrics` endpoint for prometheus scraping. When left blank the `/metrics`
 	// endpoint will be available at the ListenAddress.
 	ListenMetricsAddress string
+	// ListenSocketMode is the file mode of the Unix domain socket the server
+	// listens on, if any. It defaults to DefaultSocketMode.
+	ListenSocketMode os.FileMode
 	// LogAccess decides whether to emit logs for each requested route.
 	LogAccess bool
+	// OpenAPIInfo describes the API in the OpenAPI document served at
+	// OpenAPIPath. Its title defaults to ServiceName and its version to
+	// DefaultOpenAPIVersion.
+	OpenAPIInfo openapi.Info
+	// OpenAPIPath is an optional path the OpenAPI document describing the
+	// endpoints is served at, e.g. /openapi.json. Endpoints implementing
+	// DescribeEndpoint are described in detail.
+	OpenAPIPath string
 	// RequestFuncs is the server's configured list of request functions. These
 	// are the custom request functions configured by the client.
 	RequestFuncs []kithttp.RequestFunc
+	// RoutesPath is an optional path the routes of the server are listed at,
+	// e.g. /debug/routes, see NewRoutes. Since the listing exposes internals of
+	// the server, it is not served unless configured.
+	RoutesPath string
 	// ServiceName is the name of the micro-service implementing the microkit
 	// server. This is used for logging and instrumentation.
 	ServiceName string
 	// TLSCAFile is the file path to the certificate root CA file, if any.
 	TLSCAFile string
+	// TLSClientCAFiles are the file paths to the CA files used to verify client
+	// certificates, if any. When given, clients are required to present a
+	// certificate issued by one of them.
+	TLSClientCAFiles []string
+	// TLSCRLFiles are the file paths to certificate revocation lists used to
+	// reject revoked client certificates, if any. The files are reloaded when
+	// they change. Requires TLSClientCAFiles.
+	TLSCRLFiles []string
+	// TLSDevSelfSigned enables the generation of an ephemeral CA and a server
+	// certificate for the host of the listen address. It must only be used for
+	// local development and cannot be combined with TLSCrtFile and TLSKeyFile.
+	TLSDevSelfSigned bool
+	// TLSDevSelfSignedDir is an optional directory the generated development
+	// certificates are written to, so that test clients can trust the generated
+	// CA. Only used in case TLSDevSelfSigned is true.
+	TLSDevSelfSignedDir string
 	// TLSKeyFilePath is the file path to the certificate public key file, if any.
+	// It may also be a PKCS#12 bundle, in which case TLSKeyFile is not needed.
 	TLSCrtFile string
 	// TLSKeyFilePath is the file path to the certificate private key file, if
 	// any.
 	TLSKeyFile string
+	// TLSKeyPairs are additional certificate and key file paths served next to
+	// TLSCrtFile and TLSKeyFile. The certificate presented to clients is
+	// selected via SNI. TLSCrtFile and TLSKeyFile, or the first key pair if they
+	// are not given, act as the default certificate.
+	TLSKeyPairs []tls.KeyPair
+	// TLSPassphrase is the source of the passphrase used to decrypt encrypted
+	// private keys and PKCS#12 bundles, if any.
+	TLSPassphrase tls.Passphrase
 	// Viper is a configuration management object.
 	Viper *viper.Viper
+	// WebSocketCheckOrigin decides whether WebSocket upgrade requests are
+	// accepted based on their Origin header. It defaults to only accepting
+	// requests of the same origin.
+	WebSocketCheckOrigin func(r *http.Request) bool
+	// WebSocketEndpoints are the WebSocket endpoints served next to the
+	// endpoints. Their connections are closed with a close frame when the
+	// server shuts down.
+	WebSocketEndpoints []WebSocketEndpoint
+	// WebSocketPingInterval is the interval in which WebSocket connections are
+	// pinged to keep them alive. Connections not answering pings within twice
+	// the interval are closed. It defaults to DefaultWebSocketPingInterval.
+	WebSocketPingInterval time.Duration
 }
 
 // New creates a new configured server object.
@@ -82,6 +220,21 @@ func New(config Config) (Server, error) {
 		config.Router = mux.NewRouter()
 	}
 
+	if config.CompressionContentTypes == nil {
+		config.CompressionContentTypes = DefaultCompressionContentTypes
+	}
+	if config.CompressionMinSize < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "compression min size must not be negative")
+	}
+	if config.CompressionMinSize == 0 {
+		config.CompressionMinSize = DefaultCompressionMinSize
+	}
+	if config.CompressionMaxRequestBodySize < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "compression max request body size must not be negative")
+	}
+	if config.CompressionMaxRequestBodySize == 0 {
+		config.CompressionMaxRequestBodySize = DefaultCompressionMaxRequestBodySize
+	}
 	if config.Endpoints == nil {
 		return nil, microerror.Maskf(invalidConfigError, "endpoints must not be empty")
 	}
@@ -91,32 +244,71 @@ func New(config Config) (Server, error) {
 	if config.HandlerWrapper == nil {
 		config.HandlerWrapper = func(h http.Handler) http.Handler { return h }
 	}
-	if config.ListenAddress == "" {
+	if config.HTTP2MaxConcurrentStreams < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "HTTP/2 max concurrent streams must not be negative")
+	}
+	if config.HTTP2MaxReadFrameSize != 0 && (config.HTTP2MaxReadFrameSize < 1<<14 || config.HTTP2MaxReadFrameSize > 1<<24) {
+		return nil, microerror.Maskf(invalidConfigError, "HTTP/2 max read frame size must be between 16KiB and 16MiB")
+	}
+	if config.HTTP2PingInterval < 0 || config.HTTP2PingTimeout < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "HTTP/2 ping interval and timeout must not be negative")
+	}
+	if config.ListenAddress == "" && config.Listener == nil && len(config.ListenAddresses) == 0 {
 		return nil, microerror.Maskf(invalidConfigError, "listen address must not be empty")
 	}
+	if config.ListenSocketMode == 0 {
+		config.ListenSocketMode = DefaultSocketMode
+	}
 	if config.RequestFuncs == nil {
 		config.RequestFuncs = []kithttp.RequestFunc{}
 	}
 	if config.ServiceName == "" {
 		config.ServiceName = "microkit"
 	}
+	if config.WebSocketPingInterval < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "WebSocket ping interval must not be negative")
+	}
+	if config.WebSocketPingInterval == 0 {
+		config.WebSocketPingInterval = DefaultWebSocketPingInterval
+	}
 	if config.TLSCrtFile == "" && config.TLSKeyFile != "" {
 		return nil, microerror.Maskf(invalidConfigError, "TLS public key must not be empty")
 	}
-	if config.TLSCrtFile != "" && config.TLSKeyFile == "" {
+	if config.TLSCrtFile != "" && config.TLSKeyFile == "" && !tls.IsPKCS12Bundle(config.TLSCrtFile) {
 		return nil, microerror.Maskf(invalidConfigError, "TLS private key must not be empty")
 	}
+	for _, p := range config.TLSKeyPairs {
+		if p.Cert == "" || (p.Key == "" && !tls.IsPKCS12Bundle(p.Cert)) {
+			return nil, microerror.Maskf(invalidConfigError, "TLS key pairs must have public and private key")
+		}
+	}
+	if config.TLSDevSelfSigned && (config.TLSCrtFile != "" || len(config.TLSKeyPairs) > 0) {
+		return nil, microerror.Maskf(invalidConfigError, "TLS development certificates must not be used together with TLS certificate files")
+	}
 	if config.Viper == nil {
 		config.Viper = viper.New()
 	}
 
-	listenURL, err := url.Parse(config.ListenAddress)
-	if err != nil {
-		return nil, microerror.Maskf(invalidConfigError, "%s", err.Error())
+	addresses := config.ListenAddresses
+	if config.ListenAddress != "" || config.Listener != nil {
+		addresses = append([]ListenAddress{{Address: config.ListenAddress, Listener: config.Listener}}, addresses...)
+	}
+
+	var err error
+	var listens []*listen
+	for _, a := range addresses {
+		u, err := newListenAddressURL(a)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		listens = append(listens, &listen{listener: a.Listener, tlsCertFiles: a.TLSCertFiles, url: u})
 	}
 
 	var listenMetricsURL *url.URL
-	if config.ListenMetricsAddress != "" {
+	if config.ListenMetricsAddress == "" && config.MetricsListener != nil {
+		listenMetricsURL = newListenURL(config.MetricsListener)
+	} else if config.ListenMetricsAddress != "" {
 		listenMetricsURL, err = url.Parse(config.ListenMetricsAddress)
 		if err != nil {
 			return nil, microerror.Maskf(invalidConfigError, "%s", err.Error())
@@ -130,6 +322,75 @@ func New(config Config) (Server, error) {
 		}
 	}
 
+	// The OpenAPI document is generated upfront, so that endpoints which
+	// cannot be described cause the server creation to fail.
+	var openAPIDocument *openapi.Document
+	if config.OpenAPIPath != "" {
+		info := config.OpenAPIInfo
+		if info.Title == "" {
+			info.Title = config.ServiceName
+		}
+		if info.Version == "" {
+			info.Version = DefaultOpenAPIVersion
+		}
+
+		openAPIDocument, err = NewOpenAPIDocument(info, config.Endpoints)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var grpcListen *listen
+	if len(config.GRPCServices) > 0 && (config.GRPCListenAddress != "" || config.GRPCListener != nil) {
+		u, err := newListenAddressURL(ListenAddress{Address: config.GRPCListenAddress, Listener: config.GRPCListener})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		grpcListen = &listen{listener: config.GRPCListener, url: u}
+	}
+
+	// Load and validate the TLS configuration upfront, so that invalid or
+	// expired certificates cause the server creation to fail instead of having
+	// the server running with broken certificates.
+	{
+		tlsListens := append([]*listen{}, listens...)
+		if grpcListen != nil {
+			tlsListens = append(tlsListens, grpcListen)
+		}
+
+		err = loadTLSConfigs(config, tlsListens)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	serverMetrics := defaultMetrics
+	metricsHandler := promhttp.Handler()
+	if config.Registry != nil {
+		serverMetrics = newMetrics()
+		err = serverMetrics.register(config.Registry)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		// The metrics of loaded TLS certificates are served next to the
+		// metrics of the server.
+		err = tls.RegisterMetrics(config.Registry)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		metricsHandler = promhttp.HandlerFor(config.Registry, promhttp.HandlerOpts{})
+	}
+
+	// Zero values of the HTTP/2 settings result in the defaults of the
+	// standard library.
+	http2Config := &http.HTTP2Config{
+		MaxConcurrentStreams: config.HTTP2MaxConcurrentStreams,
+		MaxReadFrameSize:     config.HTTP2MaxReadFrameSize,
+		PingTimeout:          config.HTTP2PingTimeout,
+		SendPingTimeout:      config.HTTP2PingInterval,
+	}
+
 	newServer := &server{
 		errorEncoder: config.ErrorEncoder,
 		logger:       config.Logger,
@@ -137,23 +398,46 @@ func New(config Config) (Server, error) {
 
 		bootOnce:          sync.Once{},
 		config:            config,
-		httpServer:        nil,
+		grpcListen:        grpcListen,
+		handlerOnce:       sync.Once{},
+		listens:           listens,
 		metricsHTTPServer: nil,
-		listenURL:         listenURL,
+		metricsListener:   config.MetricsListener,
 		listenMetricsUrl:  listenMetricsURL,
+		listenSocketMode:  config.ListenSocketMode,
+		metrics:           serverMetrics,
+		metricsHandler:    metricsHandler,
 		shutdownOnce:      sync.Once{},
-
-		enableDebugServer: config.EnableDebugServer,
-		endpoints:         config.Endpoints,
-		handlerWrapper:    config.HandlerWrapper,
-		logAccess:         config.LogAccess,
-		requestFuncs:      config.RequestFuncs,
-		serviceName:       config.ServiceName,
-		tlsCertFiles: tls.CertFiles{
-			RootCAs: []string{config.TLSCAFile},
-			Cert:    config.TLSCrtFile,
-			Key:     config.TLSKeyFile,
+		websocketConns:    map[*websocketConn]struct{}{},
+		websocketUpgrader: &websocket.Upgrader{
+			CheckOrigin: config.WebSocketCheckOrigin,
 		},
+
+		compression:              config.Compression,
+		compressionContentTypes:  config.CompressionContentTypes,
+		compressionMaxBodySize:   config.CompressionMaxRequestBodySize,
+		compressionMinSize:       config.CompressionMinSize,
+		compressionRequestBodies: config.CompressionRequestBodies,
+		enableDebugServer:        config.EnableDebugServer,
+		etags:                    config.ETags,
+		endpoints:                config.Endpoints,
+		handlerWrapper:           config.HandlerWrapper,
+		http2Cleartext:           config.HTTP2Cleartext,
+		http2Config:              http2Config,
+		logAccess:                config.LogAccess,
+		openAPIDocument:          openAPIDocument,
+		openAPIPath:              config.OpenAPIPath,
+		requestFuncs:             config.RequestFuncs,
+		routes:                   NewRoutes(config),
+		routesPath:               config.RoutesPath,
+		serviceName:              config.ServiceName,
+
+		websocketEndpoints:    config.WebSocketEndpoints,
+		websocketPingInterval: config.WebSocketPingInterval,
+	}
+
+	if len(config.GRPCServices) > 0 {
+		newServer.grpcServer = newServer.newGRPCServer(config.GRPCServices)
 	}
 
 	return newServer, nil
@@ -169,24 +453,184 @@ type server struct {
 	// Internals.
 	bootOnce          sync.Once
 	config            Config
-	httpServer        *http.Server
+	grpcListen        *listen
+	grpcServer        *grpc.Server
+	handler           http.Handler
+	handlerOnce       sync.Once
+	listens           []*listen
 	metricsHTTPServer *http.Server
-	listenURL         *url.URL
+	metricsListener   net.Listener
 	listenMetricsUrl  *url.URL
+	listenSocketMode  os.FileMode
+	metrics           *metrics
+	metricsHandler    http.Handler
 	shutdownOnce      sync.Once
+	// websocketClosed, websocketConns and websocketWaitGroup track the open
+	// WebSocket connections, which are protected by websocketMutex.
+	websocketClosed    bool
+	websocketConns     map[*websocketConn]struct{}
+	websocketMutex     sync.Mutex
+	websocketUpgrader  *websocket.Upgrader
+	websocketWaitGroup sync.WaitGroup
 
 	// Settings.
-	enableDebugServer bool
-	endpoints         []Endpoint
-	handlerWrapper    func(h http.Handler) http.Handler
-	logAccess         bool
-	requestFuncs      []kithttp.RequestFunc
-	serviceName       string
-	tlsCertFiles      tls.CertFiles
+	compression              bool
+	compressionContentTypes  []string
+	compressionMaxBodySize   int64
+	compressionMinSize       int
+	compressionRequestBodies bool
+	enableDebugServer        bool
+	etags                    bool
+	endpoints                []Endpoint
+	handlerWrapper           func(h http.Handler) http.Handler
+	http2Cleartext           bool
+	http2Config              *http.HTTP2Config
+	logAccess                bool
+	openAPIDocument          *openapi.Document
+	openAPIPath              string
+	requestFuncs             []kithttp.RequestFunc
+	routes                   []Route
+	routesPath               string
+	serviceName              string
+
+	websocketEndpoints    []WebSocketEndpoint
+	websocketPingInterval time.Duration
 }
 
 func (s *server) Boot() {
 	s.bootOnce.Do(func() {
+		handler := s.Handler()
+
+		// If the user provided a specific url for the metrics endpoint:
+		if s.listenMetricsUrl != nil {
+			// Register prometheus metrics endpoint to a different server as the rest
+			// of the endpoints.
+			go func() {
+				s.logger.Log("level", "debug", "message", fmt.Sprintf("running metrics server at %s", s.listenMetricsUrl.String()))
+
+				metricsRouter := mux.NewRouter()
+				metricsRouter.Path("/metrics").Handler(s.metricsHandler)
+
+				s.metricsHTTPServer = &http.Server{
+					Addr:              s.listenMetricsUrl.Host,
+					Handler:           metricsRouter,
+					IdleTimeout:       120 * time.Second,
+					ReadHeaderTimeout: 60 * time.Second,
+					ReadTimeout:       60 * time.Second,
+					WriteTimeout:      60 * time.Second,
+				}
+
+				listener := s.metricsListener
+				if listener == nil {
+					var err error
+					listener, err = newListener(s.listenMetricsUrl, s.listenSocketMode)
+					if err != nil {
+						panic(err)
+					}
+				}
+
+				err := s.metricsHTTPServer.Serve(listener)
+				if IsServerClosed(err) {
+					// We get a closed error in case the server is shutting down. We expect
+					// this at times so we just fall through here.
+				} else if err != nil {
+					panic(err)
+				}
+			}()
+		}
+
+		if s.enableDebugServer {
+			go func() {
+				s.logger.Log("level", "debug", "message", "running debug server at http://127.0.0.1:6060/debug")
+				// When net/http/pprof is imported, its init() registers /debug
+				// handles to DefaultServeMux automatically.
+				//nolint:gosec
+				s.logger.Log("level", "debug", "message", fmt.Sprintf("%#v", http.ListenAndServe("127.0.0.1:6060", nil)))
+			}()
+		}
+
+		// Serve the gRPC services on their own listen address, if any.
+		if s.grpcListen != nil {
+			go func() {
+				s.logger.Log("level", "debug", "message", fmt.Sprintf("running gRPC server at %s", s.grpcListen.url.String()))
+
+				listener := s.grpcListen.listener
+				if listener == nil {
+					var err error
+					listener, err = newListener(s.grpcListen.url, s.listenSocketMode)
+					if err != nil {
+						panic(err)
+					}
+				}
+
+				err := s.grpcServer.Serve(listener)
+				if err != nil && err != grpc.ErrServerStopped {
+					panic(err)
+				}
+			}()
+		}
+
+		// Register the router which has all of the configured custom endpoints
+		// registered to every listen address.
+		for _, l := range s.listens {
+			l.httpServer = &http.Server{
+				Addr:              l.url.Host,
+				Handler:           handler,
+				IdleTimeout:       120 * time.Second,
+				ReadHeaderTimeout: 60 * time.Second,
+				ReadTimeout:       60 * time.Second,
+				WriteTimeout:      60 * time.Second,
+				TLSConfig:         l.tlsConfig,
+				HTTP2:             s.http2Config,
+			}
+
+			// HTTP/2 is always served on https:// listen addresses. Without TLS it
+			// has to be enabled explicitly, or is required by gRPC services sharing
+			// the listen address.
+			if l.tlsConfig == nil && (s.http2Cleartext || s.isGRPCMultiplexed()) {
+				protocols := &http.Protocols{}
+				protocols.SetHTTP1(true)
+				protocols.SetUnencryptedHTTP2(true)
+				l.httpServer.Protocols = protocols
+			}
+
+			go func(l *listen) {
+				s.logger.Log("level", "debug", "message", fmt.Sprintf("running server at %s", l.url.String()))
+
+				listener := l.listener
+				if listener == nil {
+					var err error
+					listener, err = newListener(l.url, s.listenSocketMode)
+					if err != nil {
+						panic(err)
+					}
+				}
+
+				var err error
+				if l.tlsConfig != nil {
+					// The certificates are already part of the TLS configuration, which
+					// is why we do not provide any certificate files here.
+					err = l.httpServer.ServeTLS(listener, "", "")
+				} else {
+					err = l.httpServer.Serve(listener)
+				}
+				if IsServerClosed(err) {
+					// We get a closed error in case the server is shutting down. We expect
+					// this at times so we just fall through here.
+				} else if err != nil {
+					panic(err)
+				}
+			}(l)
+		}
+	})
+}
+
+func (s *server) Config() Config {
+	return s.config
+}
+
+func (s *server) Handler() http.Handler {
+	s.handlerOnce.Do(func() {
 		s.router.NotFoundHandler = s.newNotFoundHandler()
 
 		// We go through all endpoints this server defines and register them to the
@@ -204,7 +648,43 @@ func (s *server) Boot() {
 						return
 					}
 
-					responseWriter, err := s.newResponseWriter(w)
+					stream := isStreamEndpoint(e)
+					if stream {
+						var cancel context.CancelFunc
+						ctx, cancel = s.newStreamContext(ctx, w, r)
+						defer cancel()
+					}
+
+					encoder := e.Encoder()
+					if s.etags && !stream {
+						ctx = NewContextWithIfMatch(ctx, r.Header.Values("If-Match"))
+						encoder = s.newETagEncoder(e, r)
+					}
+
+					if s.compressionRequestBodies {
+						r = newDecompressedRequest(w, r, s.compressionMaxBodySize)
+					}
+					if s.compression && !stream && isCompressEndpoint(e) {
+						var closeCompression func()
+						w, closeCompression = s.newCompressWriter(w, r)
+						defer closeCompression()
+					}
+
+					// Response bodies are only captured for endpoints asking for it.
+					// Streamed responses are never captured, since their bodies are
+					// not bounded.
+					var bodyBuffer *bytes.Buffer
+					var captureSize int
+					if ce, ok := e.(CaptureEndpoint); ok && !stream && ce.CaptureSize() > 0 {
+						bodyBuffer = bodyBufferPool.Get().(*bytes.Buffer)
+						captureSize = ce.CaptureSize()
+						defer func() {
+							bodyBuffer.Reset()
+							bodyBufferPool.Put(bodyBuffer)
+						}()
+					}
+
+					responseWriter, err := s.newResponseWriter(w, bodyBuffer, captureSize)
 					if err != nil {
 						s.newErrorEncoderWrapper()(ctx, err, w)
 						return
@@ -225,8 +705,9 @@ func (s *server) Boot() {
 							s.logger.Log("code", endpointCode, "endpoint", e.Name(), "level", "debug", "message", "tracking access log", "method", endpointMethod, "path", r.URL.Path)
 						}
 
-						endpointTotal.WithLabelValues(endpointCode, endpointMethod, endpointName).Inc()
-						endpointTime.WithLabelValues(endpointCode, endpointMethod, endpointName).Set(float64(time.Since(t) / time.Millisecond))
+						s.metrics.endpointTotal.WithLabelValues(endpointCode, endpointMethod, endpointName).Inc()
+						s.metrics.endpointBytes.WithLabelValues(endpointCode, endpointMethod, endpointName).Add(float64(responseWriter.BytesWritten()))
+						s.metrics.endpointTime.WithLabelValues(endpointCode, endpointMethod, endpointName).Set(float64(time.Since(t) / time.Millisecond))
 					}(time.Now())
 
 					// Combine all options this server defines. Since the interface of the
@@ -252,109 +733,232 @@ func (s *server) Boot() {
 					kithttp.NewServer(
 						s.newEndpointWrapper(e),
 						e.Decoder(),
-						e.Encoder(),
+						encoder,
 						options...,
 					).ServeHTTP(responseWriter, r)
 				})))
 			}(e)
 		}
 
-		// If the user provided a specific url for the metrics endpoint:
-		if s.listenMetricsUrl != nil {
-			// Register prometheus metrics endpoint to a different server as the rest
-			// of the endpoints.
-			go func() {
-				s.logger.Log("level", "debug", "message", fmt.Sprintf("running metrics server at %s", s.listenMetricsUrl.String()))
-
-				metricsRouter := mux.NewRouter()
-				metricsRouter.Path("/metrics").Handler(promhttp.Handler())
+		// WebSocket endpoints are registered next to the endpoints. Their
+		// connections are upgraded and served outside of the go-kit pipeline.
+		for _, e := range s.websocketEndpoints {
+			s.router.Methods(http.MethodGet).Path(e.Path()).Handler(s.handlerWrapper(s.newWebSocketHandler(e)))
+		}
 
-				s.metricsHTTPServer = &http.Server{
-					Addr:              s.listenMetricsUrl.Host,
-					Handler:           metricsRouter,
-					IdleTimeout:       120 * time.Second,
-					ReadHeaderTimeout: 60 * time.Second,
-					ReadTimeout:       60 * time.Second,
-					WriteTimeout:      60 * time.Second,
-				}
+		if s.openAPIPath != "" {
+			s.router.Methods(http.MethodGet).Path(s.openAPIPath).Handler(s.handlerWrapper(s.newOpenAPIHandler()))
+		}
 
-				err := s.metricsHTTPServer.ListenAndServe()
-				if IsServerClosed(err) {
-					// We get a closed error in case the server is shutting down. We expect
-					// this at times so we just fall through here.
-				} else if err != nil {
-					panic(err)
-				}
-			}()
-		} else {
-			// Register prometheus metrics endpoint to the same server as the rest of
-			// the endpoints.
-			s.router.Path("/metrics").Handler(promhttp.Handler())
+		if s.routesPath != "" {
+			s.router.Methods(http.MethodGet).Path(s.routesPath).Handler(s.handlerWrapper(s.newRoutesHandler()))
 		}
 
-		if s.enableDebugServer {
-			go func() {
-				s.logger.Log("level", "debug", "message", "running debug server at http://127.0.0.1:6060/debug")
-				// When net/http/pprof is imported, its init() registers /debug
-				// handles to DefaultServeMux automatically.
-				//nolint:gosec
-				s.logger.Log("level", "debug", "message", fmt.Sprintf("%#v", http.ListenAndServe("127.0.0.1:6060", nil)))
-			}()
+		// Register the prometheus metrics endpoint to the same router as the rest
+		// of the endpoints, unless the user provided a specific url for the
+		// metrics endpoint.
+		if s.listenMetricsUrl == nil {
+			s.router.Path("/metrics").Handler(s.metricsHandler)
 		}
 
-		// Register the router which has all of the configured custom endpoints
-		// registered.
-		s.httpServer = &http.Server{
-			Addr:              s.listenURL.Host,
-			Handler:           s.router,
-			IdleTimeout:       120 * time.Second,
-			ReadHeaderTimeout: 60 * time.Second,
-			ReadTimeout:       60 * time.Second,
-			WriteTimeout:      60 * time.Second,
+		s.handler = s.router
+		if s.isGRPCMultiplexed() {
+			s.handler = s.newGRPCHandler(s.router)
 		}
+	})
 
-		go func() {
-			s.logger.Log("level", "debug", "message", fmt.Sprintf("running server at %s", s.listenURL.String()))
+	return s.handler
+}
 
-			if s.listenURL.Scheme == "https" {
-				tlsConfig, err := tls.LoadTLSConfig(s.tlsCertFiles)
-				if err != nil {
-					panic(err)
-				}
-				s.httpServer.TLSConfig = tlsConfig
-			}
+// isCompressEndpoint expresses whether the responses of the given endpoint may
+// be compressed.
+func isCompressEndpoint(e Endpoint) bool {
+	ce, ok := e.(CompressEndpoint)
+	return !ok || ce.Compress()
+}
 
-			err := s.httpServer.ListenAndServe()
-			if IsServerClosed(err) {
-				// We get a closed error in case the server is shutting down. We expect
-				// this at times so we just fall through here.
-			} else if err != nil {
-				panic(err)
-			}
-		}()
-	})
+// isStreamEndpoint expresses whether the given endpoint streams its response.
+func isStreamEndpoint(e Endpoint) bool {
+	se, ok := e.(StreamEndpoint)
+	return ok && se.Stream()
 }
 
-func (s *server) Config() Config {
-	return s.config
+// isGRPCMultiplexed expresses whether gRPC services are served on the listen
+// addresses of the HTTP endpoints.
+func (s *server) isGRPCMultiplexed() bool {
+	return s.grpcServer != nil && s.grpcListen == nil
 }
 
 func (s *server) Shutdown() {
 	s.shutdownOnce.Do(func() {
-		// Stop the HTTP server gracefully and wait some time for open connections
-		// to be closed. Then force it to be stopped.
-		go func() {
-			err := s.httpServer.Shutdown(context.Background())
-			if err != nil {
-				s.logger.Log("level", "error", "message", "shutting down server failed", "stack", fmt.Sprintf("%#v", err))
+		// All listen addresses are shut down together, so that the server stops
+		// accepting connections everywhere at once.
+		var wg sync.WaitGroup
+		for _, l := range s.listens {
+			// In case the server was never booted there is nothing to stop.
+			if l.httpServer == nil {
+				continue
 			}
+
+			wg.Add(1)
+			go func(httpServer *http.Server) {
+				defer wg.Done()
+				s.shutdownHTTPServer(httpServer)
+			}(l.httpServer)
+		}
+		if s.grpcServer != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.shutdownGRPCServer()
+			}()
+		}
+		// Hijacked WebSocket connections are not closed by the HTTP servers.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.shutdownWebSocketConns()
 		}()
-		<-time.After(3 * time.Second)
-		err := s.httpServer.Close()
+		wg.Wait()
+	})
+}
+
+// shutdownGRPCServer stops the gRPC server gracefully and waits some time for
+// open calls to be finished. Then it forces it to be stopped.
+func (s *server) shutdownGRPCServer() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.grpcServer.GracefulStop()
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		s.grpcServer.Stop()
+	}
+}
+
+// shutdownHTTPServer stops the given HTTP server gracefully and waits some
+// time for open connections to be closed. Then it forces it to be stopped.
+func (s *server) shutdownHTTPServer(httpServer *http.Server) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := httpServer.Shutdown(context.Background())
 		if err != nil {
-			s.logger.Log("level", "error", "message", "closing server failed", "stack", fmt.Sprintf("%#v", err))
+			s.logger.Log("level", "error", "message", "shutting down server failed", "stack", fmt.Sprintf("%#v", err))
 		}
-	})
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+	}
+	err := httpServer.Close()
+	if err != nil {
+		s.logger.Log("level", "error", "message", "closing server failed", "stack", fmt.Sprintf("%#v", err))
+	}
+}
+
+// loadTLSConfigs loads the TLS configurations of all given https listen
+// addresses. Listen addresses without own TLS settings share the TLS
+// configuration of the server configuration.
+func loadTLSConfigs(config Config, listens []*listen) error {
+	var hosts []string
+	var shared []*listen
+	for _, l := range listens {
+		if l.url.Scheme != "https" {
+			continue
+		}
+
+		if l.tlsCertFiles.IsEmpty() {
+			hosts = append(hosts, l.url.Hostname())
+			shared = append(shared, l)
+			continue
+		}
+
+		if l.tlsCertFiles.Cert == "" && len(l.tlsCertFiles.KeyPairs) == 0 {
+			return microerror.Maskf(invalidConfigError, "TLS certificate of %s must not be empty", l.url.String())
+		}
+		if l.tlsCertFiles.Logger == nil {
+			l.tlsCertFiles.Logger = config.Logger
+		}
+
+		tlsConfig, err := tls.LoadTLSConfig(l.tlsCertFiles)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		l.tlsConfig = tlsConfig
+	}
+
+	if len(shared) == 0 {
+		return nil
+	}
+
+	var tlsConfig *cryptotls.Config
+	if config.TLSDevSelfSigned {
+		var err error
+		tlsConfig, err = newDevTLSConfig(config, hosts)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else {
+		if config.TLSCrtFile == "" && len(config.TLSKeyPairs) == 0 {
+			return microerror.Maskf(invalidConfigError, "TLS certificate must not be empty when listening on https")
+		}
+
+		tlsCertFiles := tls.CertFiles{
+			Cert:       config.TLSCrtFile,
+			Key:        config.TLSKeyFile,
+			ClientCAs:  config.TLSClientCAFiles,
+			CRLs:       config.TLSCRLFiles,
+			KeyPairs:   config.TLSKeyPairs,
+			Passphrase: config.TLSPassphrase,
+			Logger:     config.Logger,
+		}
+		if config.TLSCAFile != "" {
+			tlsCertFiles.RootCAs = []string{config.TLSCAFile}
+		}
+
+		var err error
+		tlsConfig, err = tls.LoadTLSConfig(tlsCertFiles)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, l := range shared {
+		l.tlsConfig = tlsConfig
+	}
+
+	return nil
+}
+
+// newDevTLSConfig generates ephemeral development certificates for the given
+// hosts and creates the TLS configuration serving them. The certificates are
+// written to the configured directory, if any.
+func newDevTLSConfig(config Config, hosts []string) (*cryptotls.Config, error) {
+	certs, err := tls.GenerateDevCertificates(hosts)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if config.TLSDevSelfSignedDir != "" {
+		files, err := certs.WriteFiles(config.TLSDevSelfSignedDir)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		config.Logger.Log("level", "warning", "message", fmt.Sprintf("using self-signed development certificates, CA written to %s", files.RootCAs[0]))
+	} else {
+		config.Logger.Log("level", "warning", "message", "using self-signed development certificates")
+	}
+
+	tlsConfig, err := certs.TLSConfig()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return tlsConfig, nil
 }
 
 // newEndpointWrapper creates a new wrapped endpoint function essentially
@@ -391,8 +995,11 @@ func (s *server) newErrorEncoderWrapper() kithttp.ErrorEncoder {
 		// be recognized by most of the clients out there. This is because in the
 		// next call to the errorEncoder below the client's implementation of the
 		// errorEncoder probably writes the status code header, which marks the
-		// beginning of trailing headers in HTTP.
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		// beginning of trailing headers in HTTP. Error bodies are encoded using
+		// the codec negotiated via the Accept header of the request, unless it
+		// cannot encode them, in which case JSON is used.
+		codec := newErrorCodec(ctx)
+		w.Header().Set("Content-Type", newContentTypeHeader(codec))
 
 		// Create the microkit specific response error, which acts as error wrapper
 		// within the client's error encoder. It is used to propagate response codes
@@ -405,9 +1012,14 @@ func (s *server) newErrorEncoderWrapper() kithttp.ErrorEncoder {
 			if err != nil {
 				panic(err)
 			}
+
+			builtin, ok := newBuiltinError(serverError)
+			if ok {
+				responseError.SetCode(builtin.Code)
+			}
 		}
 
-		rw, err := s.newResponseWriter(w)
+		rw, err := s.newResponseWriter(w, nil, 0)
 		if err != nil {
 			panic(err)
 		}
@@ -424,15 +1036,22 @@ func (s *server) newErrorEncoderWrapper() kithttp.ErrorEncoder {
 		// Emit metrics about the occured errors. That way we can feed our
 		// instrumentation stack to have nice dashboards to get a picture about the
 		// general system health.
-		errorTotal.WithLabelValues().Inc()
+		s.metrics.errorTotal.WithLabelValues().Inc()
 
 		// Write the actual response body in case no response was already written
 		// inside the error encoder.
 		if !rw.HasWritten() {
-			err := json.NewEncoder(rw).Encode(map[string]interface{}{
-				"code":  responseError.Code(),
-				"error": responseError.Message(),
-				"from":  s.serviceName,
+			// Errors of the server itself have their own status codes, which
+			// are used unless the error encoder wrote another one.
+			builtin, ok := newBuiltinError(serverError)
+			if ok && rw.StatusCode() == http.StatusOK {
+				rw.WriteHeader(builtin.StatusCode)
+			}
+
+			err := codec.Encode(rw, errorBody{
+				Code:  responseError.Code(),
+				Error: responseError.Message(),
+				From:  s.serviceName,
 			})
 			if err != nil {
 				panic(err)
@@ -457,19 +1076,21 @@ func (s *server) newNotFoundHandler() http.Handler {
 			endpointMethod := strings.ToLower(r.Method)
 			endpointName := "notfound"
 
-			endpointTotal.WithLabelValues(endpointCode, endpointMethod, endpointName).Inc()
-			endpointTime.WithLabelValues(endpointCode, endpointMethod, endpointName).Set(float64(time.Since(t) / time.Millisecond))
+			s.metrics.endpointTotal.WithLabelValues(endpointCode, endpointMethod, endpointName).Inc()
+			s.metrics.endpointTime.WithLabelValues(endpointCode, endpointMethod, endpointName).Set(float64(time.Since(t) / time.Millisecond))
 
-			errorTotal.WithLabelValues().Inc()
+			s.metrics.errorTotal.WithLabelValues().Inc()
 		}(time.Now())
 
-		// Write the actual response body.
+		// Write the actual response body using the codec negotiated via the
+		// Accept header of the request.
+		codec := newErrorCodec(NewContextWithCodec(context.Background(), negotiateCodec(r)))
+		w.Header().Set("Content-Type", newContentTypeHeader(codec))
 		w.WriteHeader(http.StatusNotFound)
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"code":  CodeResourceNotFound,
-			"error": errMessage,
-			"from":  s.serviceName,
+		err := codec.Encode(w, errorBody{
+			Code:  CodeResourceNotFound,
+			Error: errMessage,
+			From:  s.serviceName,
 		})
 		if err != nil {
 			panic(err)
@@ -478,25 +1099,66 @@ func (s *server) newNotFoundHandler() http.Handler {
 }
 
 // newRequestContext creates a new request context and enriches it with request
-// relevant information. E.g. here we put the HTTP X-Idempotency-Key header into
-// the request context, if any. We also check if there is a transaction response
-// already tracked for the given transaction ID. This information is then stored
-// within the given request context as well. Note that we initialize the
-// information about the tracked state of the transaction response with false,
-// to always have a valid state available within the request context.
+// relevant information. E.g. here we put the HTTP X-Request-ID header into the
+// request context, or generate a new request ID in case the client did not
+// provide any. The request ID is also returned to the client using the same
+// header. Trace headers are put into the request context as well, so that they
+// can be propagated to outgoing requests. The codec negotiated for the
+// response is put into the request context too.
 func (s *server) newRequestContext(w http.ResponseWriter, r *http.Request) (context.Context, error) {
 	ctx := context.Background()
 
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, requestID)
+
+	ctx = NewContextWithRequestID(ctx, requestID)
+	ctx = NewContextWithTraceHeaders(ctx, r.Header)
+
+	codec := negotiateCodec(r)
+	if codec != nil {
+		ctx = NewContextWithCodec(ctx, codec)
+	}
+
 	return ctx, nil
 }
 
+// newStreamContext prepares the given request context for streaming the
+// response of a stream endpoint. The returned context is canceled once the
+// client disconnects and carries the Last-Event-ID header of reconnecting SSE
+// clients. The write timeout of the server is removed for the current request,
+// since streams are expected to be open for longer.
+func (s *server) newStreamContext(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(r.Context(), cancel)
+
+	lastEventID := r.Header.Get(LastEventIDHeader)
+	if lastEventID != "" {
+		ctx = NewContextWithLastEventID(ctx, lastEventID)
+	}
+
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.logger.Log("level", "warning", "message", "failed removing write deadline of stream", "stack", fmt.Sprintf("%#v", err))
+	}
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
 // newResponseWriter creates a new wrapped HTTP response writer. E.g. here we
 // create a new wrapper for the http.ResponseWriter of the current request. We
 // inject it into the called http.Handler so it can track the status code we are
 // interested in. It will help us gathering the response status code after it
 // was written by the underlying http.ResponseWriter.
-func (s *server) newResponseWriter(w http.ResponseWriter) (ResponseWriter, error) {
+func (s *server) newResponseWriter(w http.ResponseWriter, bodyBuffer *bytes.Buffer, captureSize int) (ResponseWriter, error) {
 	responseConfig := DefaultResponseWriterConfig()
+	responseConfig.BodyBuffer = bodyBuffer
+	responseConfig.BodyBufferMaxSize = captureSize
 	responseConfig.ResponseWriter = w
 	responseWriter, err := NewResponseWriter(responseConfig)
 	if err != nil {
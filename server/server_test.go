@@ -160,6 +160,65 @@ func Test_Server_Custom_HandlerWrapper(t *testing.T) {
 	}
 }
 
+// Test_Server_Handler verifies the handler returned by the server can be
+// mounted into another router without booting the server.
+func Test_Server_Handler(t *testing.T) {
+	e := testNewEndpoint(t)
+
+	config := Config{
+		Logger:        microloggertest.New(),
+		ListenAddress: "http://127.0.0.1:8000",
+		Endpoints:     []Endpoint{e},
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", newServer.Handler())
+
+	testCases := []struct {
+		Path         string
+		ExpectedCode int
+	}{
+		// Case 1 ensures configured endpoints are registered.
+		{
+			Path:         "/test-path",
+			ExpectedCode: http.StatusOK,
+		},
+		// Case 2 ensures the metrics endpoint is registered.
+		{
+			Path:         "/metrics",
+			ExpectedCode: http.StatusOK,
+		},
+		// Case 3 ensures the not found handler is registered.
+		{
+			Path:         "/unknown-path",
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for i, tc := range testCases {
+		r, err := http.NewRequest(http.MethodGet, tc.Path, nil)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		w := httptest.NewRecorder()
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != tc.ExpectedCode {
+			t.Fatal("case", i+1, "expected", tc.ExpectedCode, "got", w.Code)
+		}
+	}
+
+	// Calling Handler multiple times must not register the endpoints again.
+	if newServer.Handler() != newServer.Handler() {
+		t.Fatal("expected", "same handler", "got", "different handler")
+	}
+}
+
 type testEndpoint struct {
 	decoderExecuted        int
 	decoderRequest         string
@@ -2,12 +2,27 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"net/http"
 
 	kitendpoint "github.com/go-kit/kit/endpoint"
 	kithttp "github.com/go-kit/kit/transport/http"
 )
 
+// Codec encodes and decodes values of a content type. Codecs are registered
+// via RegisterCodec.
+type Codec interface {
+	// ContentType returns the media type of the codec without parameters, e.g.
+	// application/json.
+	ContentType() string
+	// Decode decodes the data read from the given reader into the given value.
+	Decode(r io.Reader, v interface{}) error
+	// Encode writes the encoded representation of the given value to the
+	// given writer.
+	Encode(w io.Writer, v interface{}) error
+}
+
 // Endpoint represents the management of transport logic. An endpoint defines
 // what it needs to work properly. Internally it holds a reference to the
 // service object which implements business logic and executes any workload.
@@ -35,6 +50,71 @@ type Endpoint interface {
 	Path() string
 }
 
+// CaptureEndpoint is an Endpoint capturing its response bodies in the body
+// buffer of the ResponseWriter given to its encoder, e.g. to inspect them in
+// the error encoder. Response bodies of other endpoints are not captured.
+type CaptureEndpoint interface {
+	Endpoint
+	// CaptureSize returns the maximum number of bytes of a response body being
+	// captured. Larger bodies are truncated. Capturing is disabled when zero.
+	CaptureSize() int
+}
+
+// CompressEndpoint is an Endpoint deciding whether its responses are
+// compressed in case compression is enabled via Config.Compression. Responses
+// of other endpoints are compressed in this case.
+type CompressEndpoint interface {
+	Endpoint
+	// Compress expresses whether the responses of the endpoint are compressed.
+	Compress() bool
+}
+
+// DescribeEndpoint is an Endpoint describing its requests, responses and
+// errors in the OpenAPI document of the server, see Config.OpenAPIPath.
+// Endpoints created via NewEndpoint describe themselves.
+type DescribeEndpoint interface {
+	Endpoint
+	// Describe returns the description of the endpoint.
+	Describe() EndpointDescription
+}
+
+// ETagEndpoint is an Endpoint supplying the entity tags of its responses in
+// case Config.ETags is enabled, e.g. based on resource versions. That way
+// conditional requests are answered without encoding and hashing the response
+// body.
+type ETagEndpoint interface {
+	Endpoint
+	// ETag returns the entity tag of the given response as returned by the
+	// endpoint, e.g. "v42" or W/"v42". Unquoted entity tags are quoted. In
+	// case it returns an empty entity tag, it is computed from the response
+	// body.
+	ETag(ctx context.Context, response interface{}) (string, error)
+}
+
+// StreamEndpoint is an Endpoint streaming its response, e.g. using
+// Server-Sent Events via NewSSE. The encoder of a stream endpoint may write and
+// flush the response incrementally for as long as the request context is not
+// done. The request context of stream endpoints is canceled once the client
+// disconnects, the response body is not captured and the write timeout of the
+// server does not apply.
+type StreamEndpoint interface {
+	Endpoint
+	// Stream expresses whether the endpoint streams its response.
+	Stream() bool
+}
+
+// WebSocketEndpoint represents an endpoint upgrading requests to WebSocket
+// connections. It is registered for GET requests next to the endpoints.
+type WebSocketEndpoint interface {
+	// Handler returns the WebSocketHandler serving the upgraded connections.
+	Handler() WebSocketHandler
+	// Name returns the name of the endpoint which can be used to label metrics or
+	// annotate logs.
+	Name() string
+	// Path returns the HTTP request URL path used to register the endpoint.
+	Path() string
+}
+
 // Server manages the HTTP transport logic.
 type Server interface {
 	// Boot registers the configured endpoints and starts the server under the
@@ -42,6 +122,10 @@ type Server interface {
 	Boot()
 	// Config returns the servers configuration as given by the client.
 	Config() Config
+	// Handler registers the configured endpoints and returns the fully wired
+	// HTTP handler without listening on any address. This allows to mount the
+	// server into other routers or to serve it using custom listeners.
+	Handler() http.Handler
 	// Shutdown stops the running server gracefully.
 	Shutdown()
 }
@@ -71,11 +155,25 @@ type ResponseError interface {
 }
 
 // ResponseWriter is a wrapper for http.ResponseWriter to track the written
-// status code.
+// status code. It passes through http.Flusher, http.Hijacker and io.ReaderFrom
+// of the underlying response writer, which are best used via
+// http.NewResponseController.
 type ResponseWriter interface {
-	// BodyBuffer returns the buffer which is used to track the bytes being
-	// written to the response.
+	// BodyBuffer returns the buffer which is used to capture the bytes being
+	// written to the response. It is empty unless capturing is enabled, e.g.
+	// via CaptureEndpoint, which is expressed by Captured. Buffers of
+	// endpoints are reused, so they must not be retained after the request
+	// finished.
 	BodyBuffer() *bytes.Buffer
+	// BodyTruncated expresses whether more bytes were written to the response
+	// than captured in the body buffer due to its max size.
+	BodyTruncated() bool
+	// BytesWritten returns the number of bytes written to the response body,
+	// regardless of whether they are captured.
+	BytesWritten() int64
+	// Captured expresses whether the bytes being written to the response are
+	// captured in the body buffer.
+	Captured() bool
 	// HasWritten expresses whether the underlying response writer has already
 	// written anything to the response body.
 	HasWritten() bool
@@ -90,3 +188,14 @@ type ResponseWriter interface {
 	// that it is used to track the written status code.
 	WriteHeader(c int)
 }
+
+// SSE writes Server-Sent Events to the response of a stream endpoint. It must
+// not be used concurrently.
+type SSE interface {
+	// Send writes the given event to the client and flushes it immediately.
+	Send(event SSEEvent) error
+	// Stream sends the events received from the given channel until the channel
+	// is closed or the given context is done, e.g. because the client
+	// disconnected. Heartbeats are sent while no events are received.
+	Stream(ctx context.Context, events <-chan SSEEvent) error
+}
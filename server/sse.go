@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// LastEventIDHeader is the HTTP header reconnecting SSE clients use to
+	// send the ID of the last event they received. It is put into the request
+	// context of stream endpoints, see LastEventIDFromContext.
+	LastEventIDHeader = "Last-Event-ID"
+)
+
+// SSEEvent is a single event sent to the client of a Server-Sent Events stream.
+type SSEEvent struct {
+	// Data is the payload of the event. Multi-line data is sent using one data
+	// field per line.
+	Data string
+	// Event is the optional type of the event. Clients dispatch events
+	// without type as message events.
+	Event string
+	// ID is the optional ID of the event. Reconnecting clients send the ID of
+	// the last event they received using LastEventIDHeader, which allows to
+	// resume the stream.
+	ID string
+}
+
+// SSEConfig represents the configuration used to create a new Server-Sent
+// Events stream.
+type SSEConfig struct {
+	// Heartbeat is the interval in which comments are sent by Stream while no
+	// events are sent. That way idle connections are kept alive by proxies and
+	// load balancers. Heartbeats are disabled when zero.
+	Heartbeat time.Duration
+	// ResponseWriter is the response writer of the stream endpoint the events
+	// are written to. It must support flushing.
+	ResponseWriter http.ResponseWriter
+	// Retry is the reconnection time clients should use in case the connection
+	// is lost. The client's default is used when zero.
+	Retry time.Duration
+}
+
+// DefaultSSEConfig provides a default configuration to create a new
+// Server-Sent Events stream by best effort.
+func DefaultSSEConfig() SSEConfig {
+	return SSEConfig{
+		// Settings.
+		Heartbeat:      15 * time.Second,
+		ResponseWriter: nil,
+		Retry:          0,
+	}
+}
+
+// NewSSE creates a new Server-Sent Events stream. It writes the response
+// headers of the stream, which is why it has to be called before anything
+// else is written to the response, typically in the encoder of a
+// StreamEndpoint.
+func NewSSE(config SSEConfig) (SSE, error) {
+	// Settings.
+	if config.Heartbeat < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "heartbeat must not be negative")
+	}
+	if config.ResponseWriter == nil {
+		return nil, microerror.Maskf(invalidConfigError, "response writer must not be empty")
+	}
+	if config.Retry < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "retry must not be negative")
+	}
+
+	newSSE := &sse{
+		// Internals.
+		controller: http.NewResponseController(config.ResponseWriter),
+
+		// Settings.
+		heartbeat:      config.Heartbeat,
+		responseWriter: config.ResponseWriter,
+	}
+
+	h := config.ResponseWriter.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	config.ResponseWriter.WriteHeader(http.StatusOK)
+
+	if config.Retry > 0 {
+		err := newSSE.write(fmt.Sprintf("retry: %d\n\n", config.Retry.Milliseconds()))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	} else {
+		err := newSSE.flush()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return newSSE, nil
+}
+
+type sse struct {
+	// Internals.
+	controller *http.ResponseController
+
+	// Settings.
+	heartbeat      time.Duration
+	responseWriter http.ResponseWriter
+}
+
+func (s *sse) Send(event SSEEvent) error {
+	var b strings.Builder
+
+	if event.ID != "" {
+		if strings.ContainsAny(event.ID, "\r\n\x00") {
+			return microerror.Maskf(invalidEventError, "ID must not contain newlines or null characters")
+		}
+		b.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Event != "" {
+		if strings.ContainsAny(event.Event, "\r\n") {
+			return microerror.Maskf(invalidEventError, "event must not contain newlines")
+		}
+		b.WriteString("event: " + event.Event + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(event.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	err := s.write(b.String())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *sse) Stream(ctx context.Context, events <-chan SSEEvent) error {
+	var heartbeat <-chan time.Time
+	if s.heartbeat > 0 {
+		t := time.NewTicker(s.heartbeat)
+		defer t.Stop()
+		heartbeat = t.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			err := s.Send(event)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		case <-heartbeat:
+			err := s.write(": heartbeat\n\n")
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+}
+
+func (s *sse) flush() error {
+	err := s.controller.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *sse) write(v string) error {
+	_, err := s.responseWriter.Write([]byte(v))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = s.flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+type lastEventIDKey struct{}
+
+// NewContextWithLastEventID returns a copy of the given context carrying the
+// given last event ID.
+func NewContextWithLastEventID(ctx context.Context, lastEventID string) context.Context {
+	return context.WithValue(ctx, lastEventIDKey{}, lastEventID)
+}
+
+// LastEventIDFromContext returns the ID of the last event a reconnecting SSE
+// client received, if any. Stream endpoints use it to resume streams.
+func LastEventIDFromContext(ctx context.Context) (string, bool) {
+	lastEventID, ok := ctx.Value(lastEventIDKey{}).(string)
+	return lastEventID, ok && lastEventID != ""
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Test_Server_SSE ensures stream endpoints flush Server-Sent Events to the
+// client as they are sent, resume streams using the Last-Event-ID header and
+// stop streaming once the client disconnects.
+func Test_Server_SSE(t *testing.T) {
+	testCases := []struct {
+		LastEventID    string
+		ExpectedEvents []string
+	}{
+		// Case 1 ensures all events are streamed to new clients.
+		{
+			LastEventID:    "",
+			ExpectedEvents: []string{"id: 1\nevent: test\ndata: test-1\n", "id: 2\nevent: test\ndata: test-2\n"},
+		},
+		// Case 2 ensures reconnecting clients resume after the last event ID.
+		{
+			LastEventID:    "1",
+			ExpectedEvents: []string{"id: 2\nevent: test\ndata: test-2\n", "id: 3\nevent: test\ndata: test-3\n"},
+		},
+	}
+
+	for i, tc := range testCases {
+		e := &testStreamEndpoint{
+			testEndpoint: testNewEndpoint(t).(*testEndpoint),
+			done:         make(chan struct{}),
+		}
+
+		config := Config{
+			Logger:   microloggertest.New(),
+			Registry: prometheus.NewRegistry(),
+
+			Endpoints:     []Endpoint{e},
+			ListenAddress: "http://127.0.0.1:8000",
+		}
+		newServer, err := New(config)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		s := httptest.NewServer(newServer.Handler())
+
+		r, err := http.NewRequest(http.MethodGet, s.URL+"/test-path", nil)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		if tc.LastEventID != "" {
+			r.Header.Set(LastEventIDHeader, tc.LastEventID)
+		}
+
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		if res.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatal("case", i+1, "expected", "text/event-stream", "got", res.Header.Get("Content-Type"))
+		}
+
+		// The stream is never finished by the endpoint, so the events can only
+		// be read in case they are flushed.
+		reader := bufio.NewReader(res.Body)
+		var events []string
+		var event string
+		for len(events) < len(tc.ExpectedEvents) {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			if strings.HasPrefix(line, "retry:") || line == "\n" && event == "" {
+				continue
+			}
+			if line == "\n" {
+				events = append(events, event)
+				event = ""
+				continue
+			}
+			event += line
+		}
+		for j, expected := range tc.ExpectedEvents {
+			if events[j] != expected {
+				t.Fatal("case", i+1, "expected", expected, "got", events[j])
+			}
+		}
+
+		res.Body.Close()
+
+		select {
+		case <-e.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("case", i+1, "expected", "stream to stop", "got", "stream still running")
+		}
+
+		s.Close()
+	}
+}
+
+type testStreamEndpoint struct {
+	*testEndpoint
+	done chan struct{}
+}
+
+func (e *testStreamEndpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		defer close(e.done)
+
+		config := DefaultSSEConfig()
+		config.ResponseWriter = w
+		config.Retry = time.Second
+		newSSE, err := NewSSE(config)
+		if err != nil {
+			return err
+		}
+
+		start := 1
+		lastEventID, ok := LastEventIDFromContext(ctx)
+		if ok {
+			start, err = strconv.Atoi(lastEventID)
+			if err != nil {
+				return err
+			}
+			start++
+		}
+
+		events := make(chan SSEEvent, 2)
+		for i := start; i < start+2; i++ {
+			events <- SSEEvent{
+				Data:  "test-" + strconv.Itoa(i),
+				Event: "test",
+				ID:    strconv.Itoa(i),
+			}
+		}
+
+		return newSSE.Stream(ctx, events)
+	}
+}
+
+func (e *testStreamEndpoint) Stream() bool {
+	return true
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/micrologger/loggermeta"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// DefaultWebSocketPingInterval is the interval in which WebSocket
+	// connections are pinged in case no other interval is configured.
+	DefaultWebSocketPingInterval = 30 * time.Second
+)
+
+// WebSocketHandler serves a single upgraded WebSocket connection. The given
+// context is canceled once the server shuts down or pinging the client
+// fails. Reads fail in case the client does not answer pings anymore. The
+// connection is closed with a close frame once the handler returns. Pongs are
+// only processed while the handler reads from the connection, which is why
+// handlers must keep reading, even if they only write messages.
+type WebSocketHandler func(ctx context.Context, conn *websocket.Conn) error
+
+// websocketConn is an open WebSocket connection tracked by the server, so
+// that it can be closed during shutdown.
+type websocketConn struct {
+	cancel context.CancelFunc
+	conn   *websocket.Conn
+}
+
+// newWebSocketHandler returns the HTTP handler upgrading requests of the
+// given WebSocket endpoint and serving the upgraded connections.
+func (s *server) newWebSocketHandler(e WebSocketEndpoint) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := s.newRequestContext(w, r)
+		if err != nil {
+			s.newErrorEncoderWrapper()(ctx, err, w)
+			return
+		}
+
+		endpointName := strings.ReplaceAll(e.Name(), "/", "_")
+
+		conn, err := s.websocketUpgrader.Upgrade(w, r, http.Header{RequestIDHeader: w.Header().Values(RequestIDHeader)})
+		if err != nil {
+			// The upgrader already responded with the appropriate HTTP error.
+			s.logger.Log("level", "error", "message", "upgrading WebSocket connection failed", "endpoint", e.Name(), "stack", fmt.Sprintf("%#v", err))
+			s.metrics.errorTotal.WithLabelValues().Inc()
+			return
+		}
+
+		// Each connection logs with its endpoint, request ID and remote address,
+		// so that all logs of a connection can be correlated.
+		{
+			meta := loggermeta.New()
+			meta.KeyVals["endpoint"] = e.Name()
+			meta.KeyVals["remote"] = r.RemoteAddr
+			requestID, _ := RequestIDFromContext(ctx)
+			meta.KeyVals["requestID"] = requestID
+			ctx = loggermeta.NewContext(ctx, meta)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		c := &websocketConn{
+			cancel: cancel,
+			conn:   conn,
+		}
+		if !s.trackWebSocketConn(c) {
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(time.Second))
+			conn.Close()
+			return
+		}
+		defer s.untrackWebSocketConn(c)
+
+		s.metrics.websocketTotal.WithLabelValues(endpointName).Inc()
+		s.metrics.websocketOpen.WithLabelValues(endpointName).Inc()
+		defer s.metrics.websocketOpen.WithLabelValues(endpointName).Dec()
+
+		if s.logAccess {
+			s.logger.LogCtx(ctx, "level", "debug", "message", "accepted WebSocket connection", "path", r.URL.Path)
+		}
+
+		// The read deadline of the connection is extended whenever a pong is
+		// received, so reads of connections not answering pings fail.
+		{
+			timeout := 2 * s.websocketPingInterval
+			_ = conn.SetReadDeadline(time.Now().Add(timeout))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(timeout))
+			})
+		}
+		go s.pingWebSocketConn(ctx, c)
+
+		err = e.Handler()(ctx, conn)
+
+		closeCode := websocket.CloseNormalClosure
+		closeText := ""
+		if err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			s.logger.LogCtx(ctx, "level", "error", "message", "stop WebSocket connection processing due to error", "stack", fmt.Sprintf("%#v", err))
+			s.metrics.errorTotal.WithLabelValues().Inc()
+
+			closeCode = websocket.CloseInternalServerErr
+			closeText = "internal error"
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, closeText), time.Now().Add(time.Second))
+		conn.Close()
+
+		if s.logAccess {
+			s.logger.LogCtx(ctx, "level", "debug", "message", "closed WebSocket connection", "path", r.URL.Path)
+		}
+	})
+}
+
+// pingWebSocketConn pings the given connection until its context is done. The
+// context is canceled in case pinging fails.
+func (s *server) pingWebSocketConn(ctx context.Context, c *websocketConn) {
+	t := time.NewTicker(s.websocketPingInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.websocketPingInterval))
+			if err != nil {
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+// shutdownWebSocketConns sends a close frame to all open WebSocket
+// connections and cancels their contexts. It waits some time for the handlers
+// to return. Then it closes the remaining connections.
+func (s *server) shutdownWebSocketConns() {
+	s.websocketMutex.Lock()
+	s.websocketClosed = true
+	var conns []*websocketConn
+	for c := range s.websocketConns {
+		conns = append(conns, c)
+	}
+	s.websocketMutex.Unlock()
+
+	for _, c := range conns {
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(time.Second))
+		c.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.websocketWaitGroup.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		for _, c := range conns {
+			c.conn.Close()
+		}
+	}
+}
+
+// trackWebSocketConn registers the given connection to be closed during
+// shutdown. It returns false in case the server is already shutting down.
+func (s *server) trackWebSocketConn(c *websocketConn) bool {
+	s.websocketMutex.Lock()
+	defer s.websocketMutex.Unlock()
+
+	if s.websocketClosed {
+		return false
+	}
+
+	s.websocketConns[c] = struct{}{}
+	s.websocketWaitGroup.Add(1)
+
+	return true
+}
+
+func (s *server) untrackWebSocketConn(c *websocketConn) {
+	s.websocketMutex.Lock()
+	defer s.websocketMutex.Unlock()
+
+	delete(s.websocketConns, c)
+	s.websocketWaitGroup.Done()
+}
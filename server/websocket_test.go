@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Test_Server_WebSocket ensures WebSocket endpoints serve upgraded
+// connections, track open connections and close them with a close frame when
+// the server shuts down.
+func Test_Server_WebSocket(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	config := Config{
+		Logger:   microloggertest.New(),
+		Registry: registry,
+
+		Endpoints:          []Endpoint{testNewEndpoint(t)},
+		ListenAddress:      "http://127.0.0.1:8000",
+		WebSocketEndpoints: []WebSocketEndpoint{&testWebSocketEndpoint{}},
+	}
+	newServer, err := New(config)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	s := httptest.NewServer(newServer.Handler())
+	defer s.Close()
+
+	conn, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/test-websocket", nil)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	defer conn.Close()
+
+	if res.Header.Get(RequestIDHeader) == "" {
+		t.Fatal("expected", "request ID", "got", "")
+	}
+
+	err = conn.WriteMessage(websocket.TextMessage, []byte("test-message"))
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	_, b, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if string(b) != "test-message" {
+		t.Fatal("expected", "test-message", "got", string(b))
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	var open float64
+	for _, f := range families {
+		if f.GetName() == "websocket_connections" {
+			for _, m := range f.GetMetric() {
+				open += m.GetGauge().GetValue()
+			}
+		}
+	}
+	if open != 1 {
+		t.Fatal("expected", 1, "got", open)
+	}
+
+	// The client answers the close frame of the server while reading, which
+	// lets the shutdown finish.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		newServer.Shutdown()
+	}()
+
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatal("expected", "close going away", "got", err)
+	}
+
+	<-done
+}
+
+type testWebSocketEndpoint struct{}
+
+func (e *testWebSocketEndpoint) Handler() WebSocketHandler {
+	return func(ctx context.Context, conn *websocket.Conn) error {
+		for {
+			t, b, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			err = conn.WriteMessage(t, b)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (e *testWebSocketEndpoint) Name() string {
+	return "test-websocket"
+}
+
+func (e *testWebSocketEndpoint) Path() string {
+	return "/test-websocket"
+}
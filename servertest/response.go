@@ -0,0 +1,78 @@
+package servertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// Response is a response of the server under test, of which the body was
+// already read.
+type Response struct {
+	t testing.TB
+
+	Body       []byte
+	Header     http.Header
+	StatusCode int
+}
+
+// AssertErrorCode fails the test in case the response is not a microkit error
+// response carrying the given code, e.g. server.CodeResourceNotFound.
+func (r *Response) AssertErrorCode(code string) {
+	r.t.Helper()
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	r.DecodeJSON(&body)
+
+	if body.Code != code {
+		r.t.Fatal("expected", code, "got", body.Code)
+	}
+}
+
+// AssertJSON fails the test in case the JSON response body is not equal to the
+// JSON encoding of the given value.
+func (r *Response) AssertJSON(expected interface{}) {
+	r.t.Helper()
+
+	b, err := json.Marshal(expected)
+	if err != nil {
+		r.t.Fatal("expected", nil, "got", err)
+	}
+
+	var e interface{}
+	err = json.Unmarshal(b, &e)
+	if err != nil {
+		r.t.Fatal("expected", nil, "got", err)
+	}
+
+	var g interface{}
+	r.DecodeJSON(&g)
+
+	if !reflect.DeepEqual(e, g) {
+		r.t.Fatal("expected", string(b), "got", string(r.Body))
+	}
+}
+
+// AssertStatus fails the test in case the response status code is not equal
+// to the given one.
+func (r *Response) AssertStatus(code int) {
+	r.t.Helper()
+
+	if r.StatusCode != code {
+		r.t.Fatal("expected", code, "got", r.StatusCode, "body", string(r.Body))
+	}
+}
+
+// DecodeJSON decodes the JSON response body into the given value and fails the
+// test in case the body cannot be decoded.
+func (r *Response) DecodeJSON(v interface{}) {
+	r.t.Helper()
+
+	err := json.Unmarshal(r.Body, v)
+	if err != nil {
+		r.t.Fatal("expected", nil, "got", err, "body", string(r.Body))
+	}
+}
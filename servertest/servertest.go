@@ -0,0 +1,221 @@
+// Package servertest provides an in-process test harness for microkit servers.
+// It builds a server.Server from endpoints and serves it either through an
+// ephemeral port or directly through its HTTP handler, without any network
+// listener. Every harness uses a dedicated prometheus registry, so that tests
+// using it can run in parallel.
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/micrologger/microloggertest"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/microkit/server"
+)
+
+// Config represents the configuration used to create a new test server.
+type Config struct {
+	// Endpoints is the list of endpoints the test server registers.
+	Endpoints []server.Endpoint
+	// ErrorEncoder is the optional error encoder of the server under test.
+	ErrorEncoder kithttp.ErrorEncoder
+	// HandlerWrapper is the optional handler wrapper of the server under test.
+	HandlerWrapper func(h http.Handler) http.Handler
+	// Listen decides whether the server is served on an ephemeral port of the
+	// loopback interface. Otherwise requests are passed to the server's HTTP
+	// handler directly.
+	Listen bool
+	// Logger is the optional logger of the server under test. It defaults to
+	// microloggertest.New.
+	Logger micrologger.Logger
+	// RequestFuncs is the optional list of request functions of the server
+	// under test.
+	RequestFuncs []kithttp.RequestFunc
+	// ServiceName is the optional service name of the server under test.
+	ServiceName string
+}
+
+// Server is a microkit server under test.
+type Server struct {
+	t testing.TB
+
+	handler    http.Handler
+	httpServer *httptest.Server
+	registry   *prometheus.Registry
+	server     server.Server
+}
+
+// New creates a new test server. Any failure fails the given test. In case the
+// server listens on an ephemeral port, it is closed once the test and all its
+// subtests completed.
+func New(t testing.TB, config Config) *Server {
+	t.Helper()
+
+	if config.Logger == nil {
+		config.Logger = microloggertest.New()
+	}
+
+	registry := prometheus.NewRegistry()
+
+	serverConfig := server.Config{
+		ErrorEncoder: config.ErrorEncoder,
+		Logger:       config.Logger,
+		Registry:     registry,
+
+		Endpoints:      config.Endpoints,
+		HandlerWrapper: config.HandlerWrapper,
+		ListenAddress:  "http://127.0.0.1:0",
+		RequestFuncs:   config.RequestFuncs,
+		ServiceName:    config.ServiceName,
+	}
+	if serverConfig.Endpoints == nil {
+		serverConfig.Endpoints = []server.Endpoint{}
+	}
+
+	newServer, err := server.New(serverConfig)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	s := &Server{
+		t: t,
+
+		handler:  newServer.Handler(),
+		registry: registry,
+		server:   newServer,
+	}
+
+	if config.Listen {
+		s.httpServer = httptest.NewServer(s.handler)
+		t.Cleanup(s.httpServer.Close)
+
+		s.waitForReadiness()
+	}
+
+	return s
+}
+
+// Handler returns the HTTP handler of the server under test.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Registry returns the prometheus registry the server under test registers
+// its metrics to.
+func (s *Server) Registry() *prometheus.Registry {
+	return s.registry
+}
+
+// Server returns the server under test.
+func (s *Server) Server() server.Server {
+	return s.server
+}
+
+// URL returns the base URL of the server under test in case it listens on an
+// ephemeral port. Otherwise the empty string is returned.
+func (s *Server) URL() string {
+	if s.httpServer == nil {
+		return ""
+	}
+
+	return s.httpServer.URL
+}
+
+// Do sends the given request to the server under test. Requests are either
+// sent through the network or passed to the server's handler directly,
+// depending on Config.Listen. Request URLs only need to specify a path.
+func (s *Server) Do(req *http.Request) *Response {
+	s.t.Helper()
+
+	var res *http.Response
+	if s.httpServer != nil {
+		u, err := req.URL.Parse(s.httpServer.URL + req.URL.RequestURI())
+		if err != nil {
+			s.t.Fatal("expected", nil, "got", err)
+		}
+
+		req = req.Clone(req.Context())
+		req.URL = u
+		req.RequestURI = ""
+		req.Host = ""
+
+		res, err = s.httpServer.Client().Do(req)
+		if err != nil {
+			s.t.Fatal("expected", nil, "got", err)
+		}
+	} else {
+		w := httptest.NewRecorder()
+		s.handler.ServeHTTP(w, req)
+		res = w.Result()
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		s.t.Fatal("expected", nil, "got", err)
+	}
+
+	r := &Response{
+		t: s.t,
+
+		Body:       body,
+		Header:     res.Header,
+		StatusCode: res.StatusCode,
+	}
+
+	return r
+}
+
+// Request sends a request with the given method and path to the server under
+// test. The given body is JSON encoded, unless it is nil.
+func (s *Server) Request(method, path string, body interface{}) *Response {
+	s.t.Helper()
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal("expected", nil, "got", err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return s.Do(req)
+}
+
+// waitForReadiness waits until the server under test accepts requests. The
+// /metrics endpoint is used to check readiness, since it is always registered.
+func (s *Server) waitForReadiness() {
+	s.t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		res, err := s.httpServer.Client().Get(s.httpServer.URL + "/metrics")
+		if err == nil {
+			res.Body.Close()
+			if res.StatusCode == http.StatusOK {
+				return
+			}
+		}
+
+		if time.Now().After(deadline) {
+			s.t.Fatal("expected", "server to become ready", "got", err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,134 @@
+package servertest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/giantswarm/microkit/server"
+)
+
+// Test_Server ensures the test server serves endpoints with and without
+// listening on an ephemeral port, while keeping metrics per server.
+func Test_Server(t *testing.T) {
+	testCases := []struct {
+		Listen bool
+	}{
+		// Case 1 ensures requests are passed to the handler directly.
+		{
+			Listen: false,
+		},
+		// Case 2 ensures requests are sent through an ephemeral port.
+		{
+			Listen: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+
+			s := New(t, Config{
+				Endpoints: []server.Endpoint{
+					&testEndpoint{method: http.MethodPost, name: "echo", path: "/echo"},
+					&testEndpoint{method: http.MethodGet, name: "fail", path: "/fail", err: errors.New("test error")},
+				},
+				ErrorEncoder: func(ctx context.Context, err error, w http.ResponseWriter) {
+					w.WriteHeader(http.StatusInternalServerError)
+				},
+				Listen:      tc.Listen,
+				ServiceName: "test-service",
+			})
+
+			if tc.Listen && s.URL() == "" {
+				t.Fatal("case", i+1, "expected", "URL", "got", "")
+			}
+
+			{
+				r := s.Request(http.MethodPost, "/echo", map[string]string{"foo": "bar"})
+				r.AssertStatus(http.StatusOK)
+				r.AssertJSON(map[string]string{"foo": "bar"})
+
+				if r.Header.Get(server.RequestIDHeader) == "" {
+					t.Fatal("case", i+1, "expected", "request ID", "got", "")
+				}
+			}
+
+			{
+				r := s.Request(http.MethodGet, "/fail", nil)
+				r.AssertStatus(http.StatusInternalServerError)
+				r.AssertErrorCode(server.CodeInternalError)
+			}
+
+			families, err := s.Registry().Gather()
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			var errorTotal float64
+			for _, f := range families {
+				if f.GetName() == "error_total" {
+					errorTotal = f.GetMetric()[0].GetCounter().GetValue()
+				}
+			}
+			if errorTotal != 1 {
+				t.Fatal("case", i+1, "expected", 1, "got", errorTotal)
+			}
+		})
+	}
+}
+
+type testEndpoint struct {
+	err    error
+	method string
+	name   string
+	path   string
+}
+
+func (e *testEndpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		var request interface{}
+		if r.Method == http.MethodPost {
+			err := json.NewDecoder(r.Body).Decode(&request)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return request, nil
+	}
+}
+
+func (e *testEndpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e *testEndpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if e.err != nil {
+			return nil, e.err
+		}
+		return request, nil
+	}
+}
+
+func (e *testEndpoint) Method() string {
+	return e.method
+}
+
+func (e *testEndpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e *testEndpoint) Name() string {
+	return e.name
+}
+
+func (e *testEndpoint) Path() string {
+	return e.path
+}
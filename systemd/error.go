@@ -0,0 +1,23 @@
+package systemd
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidEnvError = &microerror.Error{
+	Kind: "invalidEnvError",
+}
+
+// IsInvalidEnv asserts invalidEnvError.
+func IsInvalidEnv(err error) bool {
+	return microerror.Cause(err) == invalidEnvError
+}
+
+var upgradeFailedError = &microerror.Error{
+	Kind: "upgradeFailedError",
+}
+
+// IsUpgradeFailed asserts upgradeFailedError.
+func IsUpgradeFailed(err error) bool {
+	return microerror.Cause(err) == upgradeFailedError
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package systemd
+
+import (
+	"os"
+)
+
+// UpgradeSignals are the signals telling a daemon to hand its listeners to an
+// upgraded process. Upgrades are not supported on this platform.
+var UpgradeSignals = []os.Signal{}
+
+func closeOnExec(fd int) {}
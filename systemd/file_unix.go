@@ -0,0 +1,18 @@
+//go:build unix
+
+package systemd
+
+import (
+	"os"
+	"syscall"
+)
+
+// UpgradeSignals are the signals telling a daemon to hand its listeners to an
+// upgraded process.
+var UpgradeSignals = []os.Signal{syscall.SIGUSR2}
+
+// closeOnExec marks the given inherited file descriptor to be closed on exec,
+// so that it does not leak into child processes.
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
@@ -0,0 +1,140 @@
+// Package systemd implements the parts of the systemd service protocols used
+// by microkit daemons. These are socket activation via LISTEN_FDS, service
+// notifications via NOTIFY_SOCKET and the handoff of listening sockets to an
+// upgraded process.
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// ListenerGRPC is the name of the listener gRPC services accept
+	// connections on, e.g. FileDescriptorName=grpc in the systemd socket unit.
+	ListenerGRPC = "grpc"
+	// ListenerMain is the name of the listener the server accepts connections
+	// on, e.g. FileDescriptorName=main in the systemd socket unit.
+	ListenerMain = "main"
+	// ListenerMetrics is the name of the listener the metrics server accepts
+	// connections on, e.g. FileDescriptorName=metrics in the systemd socket
+	// unit.
+	ListenerMetrics = "metrics"
+)
+
+const (
+	listenFDsStart = 3
+
+	envListenFDNames = "LISTEN_FDNAMES"
+	envListenFDs     = "LISTEN_FDS"
+	envListenPID     = "LISTEN_PID"
+	// envListenPPID is set instead of LISTEN_PID when listeners are handed to
+	// an upgraded process, because the PID of a child is not known before it
+	// is started.
+	envListenPPID = "MICROKIT_LISTEN_PPID"
+)
+
+// Listeners returns the listeners passed to the current process via socket
+// activation or an upgrade, mapped by their names. Multiple listeners may
+// share a name, in which case they are returned in the given order. Listeners
+// without a name are named after their position, the first being ListenerMain
+// and the second being ListenerMetrics. The environment variables of the
+// protocol are unset, so that child processes do not inherit them. An empty
+// map is returned in case no listeners were passed.
+func Listeners() (map[string][]net.Listener, error) {
+	return listeners(listenFDsStart)
+}
+
+func listeners(start int) (map[string][]net.Listener, error) {
+	defer unsetListenEnv()
+
+	listeners := map[string][]net.Listener{}
+
+	if !isListenTarget() {
+		return listeners, nil
+	}
+
+	// The file descriptor used to notify the parent process about the
+	// readiness of an upgrade must not leak into child processes either.
+	if fd, err := strconv.Atoi(os.Getenv(envUpgradeReadyFD)); err == nil {
+		closeOnExec(fd)
+	}
+
+	n, err := strconv.Atoi(os.Getenv(envListenFDs))
+	if err != nil || n < 0 {
+		return nil, microerror.Maskf(invalidEnvError, "%s must be a positive number", envListenFDs)
+	}
+
+	var names []string
+	if os.Getenv(envListenFDNames) != "" {
+		names = strings.Split(os.Getenv(envListenFDNames), ":")
+	}
+
+	for i := 0; i < n; i++ {
+		fd := start + i
+
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		if name == "unknown" {
+			name = defaultListenerName(i)
+		}
+
+		f := newFile(fd, name)
+
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		listeners[name] = append(listeners[name], l)
+	}
+
+	return listeners, nil
+}
+
+// defaultListenerName returns the name of unnamed listeners at the given
+// position.
+func defaultListenerName(i int) string {
+	switch i {
+	case 0:
+		return ListenerMain
+	case 1:
+		return ListenerMetrics
+	}
+
+	return "unknown-" + strconv.Itoa(i)
+}
+
+// isListenTarget expresses whether the listen environment variables are meant
+// for the current process.
+func isListenTarget() bool {
+	if pid := os.Getenv(envListenPID); pid != "" {
+		return pid == strconv.Itoa(os.Getpid())
+	}
+	if ppid := os.Getenv(envListenPPID); ppid != "" {
+		return ppid == strconv.Itoa(os.Getppid())
+	}
+
+	return false
+}
+
+func unsetListenEnv() {
+	os.Unsetenv(envListenFDNames)
+	os.Unsetenv(envListenFDs)
+	os.Unsetenv(envListenPID)
+	os.Unsetenv(envListenPPID)
+}
+
+// newFile returns the file of the given inherited file descriptor, which is
+// marked to be closed on exec so that it does not leak into child processes.
+func newFile(fd int, name string) *os.File {
+	closeOnExec(fd)
+	return os.NewFile(uintptr(fd), name)
+}
@@ -0,0 +1,132 @@
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// Ready tells the service manager that the service finished starting up.
+	Ready = "READY=1"
+	// Reloading tells the service manager that the service is reloading its
+	// configuration.
+	Reloading = "RELOADING=1"
+	// Stopping tells the service manager that the service is shutting down.
+	Stopping = "STOPPING=1"
+	// Watchdog tells the service manager that the service is still alive.
+	Watchdog = "WATCHDOG=1"
+)
+
+const (
+	envNotifySocket = "NOTIFY_SOCKET"
+	envWatchdogPID  = "WATCHDOG_PID"
+	envWatchdogUSec = "WATCHDOG_USEC"
+	// envUpgradeReadyFD is the file descriptor an upgraded process writes to
+	// once it is ready, which tells the parent process to shut down.
+	envUpgradeReadyFD = "MICROKIT_UPGRADE_READY_FD"
+)
+
+// MainPID returns the state telling the service manager that the process
+// with the given PID is the main process of the service.
+func MainPID(pid int) string {
+	return fmt.Sprintf("MAINPID=%d", pid)
+}
+
+// Notify sends the given states to the service manager via the socket given
+// by NOTIFY_SOCKET. Nothing is sent in case the variable is not set. When an
+// upgraded process notifies Ready, its parent process is told to shut down
+// and the service manager is told about the new main process. Note that this
+// requires NotifyAccess=all in the service unit.
+func Notify(states ...string) error {
+	for _, s := range states {
+		if s != Ready {
+			continue
+		}
+
+		upgraded, err := notifyUpgradeReady()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if upgraded {
+			states = append([]string{MainPID(os.Getpid())}, states...)
+		}
+	}
+
+	socket := os.Getenv(envNotifySocket)
+	if socket == "" {
+		return nil
+	}
+
+	// Abstract sockets are given with a leading @, which stands for the
+	// leading null byte of their name.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(strings.Join(states, "\n")))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// WatchdogInterval returns the interval in which the service manager expects
+// Watchdog notifications. Zero is returned in case the watchdog is disabled
+// or meant for another process. Notifications should be sent at least twice
+// per interval.
+func WatchdogInterval() (time.Duration, error) {
+	if pid := os.Getenv(envWatchdogPID); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+
+	s := os.Getenv(envWatchdogUSec)
+	if s == "" {
+		return 0, nil
+	}
+
+	usec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, microerror.Maskf(invalidEnvError, "%s must be a positive number", envWatchdogUSec)
+	}
+
+	return time.Duration(usec) * time.Microsecond, nil
+}
+
+// notifyUpgradeReady tells the parent process of an upgraded process that the
+// upgrade is ready by writing to the inherited file descriptor. It expresses
+// whether the current process is an upgraded process.
+func notifyUpgradeReady() (bool, error) {
+	s := os.Getenv(envUpgradeReadyFD)
+	if s == "" {
+		return false, nil
+	}
+	os.Unsetenv(envUpgradeReadyFD)
+
+	fd, err := strconv.Atoi(s)
+	if err != nil {
+		return false, microerror.Maskf(invalidEnvError, "%s must be a file descriptor", envUpgradeReadyFD)
+	}
+
+	f := newFile(fd, "upgrade-ready")
+	defer f.Close()
+
+	_, err = f.Write([]byte{1})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
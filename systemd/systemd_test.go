@@ -0,0 +1,249 @@
+//go:build unix
+
+package systemd
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary act as the new process of Test_Upgrade.
+func TestMain(m *testing.M) {
+	if os.Getenv("MICROKIT_TEST_UPGRADE") == "child" {
+		testUpgradeChild()
+	}
+
+	os.Exit(m.Run())
+}
+
+// Test_Listeners ensures listeners passed via LISTEN_FDS are returned by their
+// names and only in case they are meant for the current process.
+func Test_Listeners(t *testing.T) {
+	testCases := []struct {
+		Env           map[string]string
+		ExpectedNames []string
+	}{
+		// Case 1 ensures listeners are returned by name.
+		{
+			Env: map[string]string{
+				envListenPID:     strconv.Itoa(os.Getpid()),
+				envListenFDs:     "1",
+				envListenFDNames: "metrics",
+			},
+			ExpectedNames: []string{ListenerMetrics},
+		},
+		// Case 2 ensures unnamed listeners are named after their position.
+		{
+			Env: map[string]string{
+				envListenPID: strconv.Itoa(os.Getpid()),
+				envListenFDs: "1",
+			},
+			ExpectedNames: []string{ListenerMain},
+		},
+		// Case 3 ensures listeners handed over by the parent process are returned.
+		{
+			Env: map[string]string{
+				envListenPPID: strconv.Itoa(os.Getppid()),
+				envListenFDs:  "1",
+			},
+			ExpectedNames: []string{ListenerMain},
+		},
+		// Case 4 ensures listeners meant for other processes are ignored.
+		{
+			Env: map[string]string{
+				envListenPID: "1",
+				envListenFDs: "1",
+			},
+			ExpectedNames: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		f, err := l.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		l.Close()
+
+		fd, err := syscall.Dup(int(f.Fd()))
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		f.Close()
+
+		for k, v := range tc.Env {
+			t.Setenv(k, v)
+		}
+
+		listeners, err := listeners(fd)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		if len(listeners) != len(tc.ExpectedNames) {
+			t.Fatal("case", i+1, "expected", len(tc.ExpectedNames), "got", len(listeners))
+		}
+		for _, name := range tc.ExpectedNames {
+			if len(listeners[name]) != 1 {
+				t.Fatal("case", i+1, "expected", 1, "got", len(listeners[name]))
+			}
+			listeners[name][0].Close()
+		}
+		if len(tc.ExpectedNames) == 0 {
+			syscall.Close(fd)
+		}
+
+		if os.Getenv(envListenFDs) != "" {
+			t.Fatal("case", i+1, "expected", "", "got", os.Getenv(envListenFDs))
+		}
+	}
+}
+
+// Test_Notify ensures states are sent to the notify socket.
+func Test_Notify(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	defer conn.Close()
+
+	t.Setenv(envNotifySocket, socket)
+
+	err = Notify(Ready, "STATUS=serving")
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	b := make([]byte, 1024)
+	err = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	if string(b[:n]) != "READY=1\nSTATUS=serving" {
+		t.Fatal("expected", "READY=1\nSTATUS=serving", "got", string(b[:n]))
+	}
+}
+
+// Test_WatchdogInterval ensures the watchdog interval is only returned for the
+// current process.
+func Test_WatchdogInterval(t *testing.T) {
+	testCases := []struct {
+		PID              string
+		USec             string
+		ExpectedInterval time.Duration
+	}{
+		// Case 1 ensures the watchdog is disabled by default.
+		{
+			PID:              "",
+			USec:             "",
+			ExpectedInterval: 0,
+		},
+		// Case 2 ensures the interval is returned.
+		{
+			PID:              strconv.Itoa(os.Getpid()),
+			USec:             "30000000",
+			ExpectedInterval: 30 * time.Second,
+		},
+		// Case 3 ensures the interval of other processes is ignored.
+		{
+			PID:              "1",
+			USec:             "30000000",
+			ExpectedInterval: 0,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Setenv(envWatchdogPID, tc.PID)
+		t.Setenv(envWatchdogUSec, tc.USec)
+
+		interval, err := WatchdogInterval()
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+		if interval != tc.ExpectedInterval {
+			t.Fatal("case", i+1, "expected", tc.ExpectedInterval, "got", interval)
+		}
+	}
+}
+
+// Test_Upgrade ensures listeners are handed over to a new process, which
+// accepts connections once the current process stopped accepting them, and
+// which takes over the watchdog of the current process. The test binary itself
+// acts as the new process.
+func Test_Upgrade(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	t.Setenv("MICROKIT_TEST_UPGRADE", "child")
+	t.Setenv(envNotifySocket, "")
+	t.Setenv(envWatchdogPID, strconv.Itoa(os.Getpid()))
+	t.Setenv(envWatchdogUSec, "30000000")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = Upgrade(ctx, map[string][]net.Listener{ListenerMain: {l}})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	defer conn.Close()
+
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if string(b) != "child 30s" {
+		t.Fatal("expected", "child 30s", "got", string(b))
+	}
+}
+
+func testUpgradeChild() {
+	listeners, err := Listeners()
+	if err != nil || len(listeners[ListenerMain]) != 1 {
+		os.Exit(1)
+	}
+
+	err = Notify(Ready)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	interval, err := WatchdogInterval()
+	if err != nil {
+		os.Exit(1)
+	}
+
+	conn, err := listeners[ListenerMain][0].Accept()
+	if err != nil {
+		os.Exit(1)
+	}
+	_, _ = conn.Write([]byte("child " + interval.String()))
+	conn.Close()
+
+	os.Exit(0)
+}
@@ -0,0 +1,136 @@
+package systemd
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrade starts a new process of the current executable with the arguments
+// of the current process and hands the given listeners to it, mapped by their
+// names. The new process receives them via Listeners. Upgrade blocks until the
+// new process notified Ready, after which the current process should stop
+// accepting connections and shut down. In case the new process exits or the
+// given context is done before, the new process is killed and the current
+// process keeps serving.
+func Upgrade(ctx context.Context, listeners map[string][]net.Listener) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var keys []string
+	for name := range listeners {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	var files []*os.File
+	var names []string
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, name := range keys {
+		for _, l := range listeners[name] {
+			fl, ok := l.(filer)
+			if !ok {
+				return microerror.Maskf(upgradeFailedError, "listener %#q cannot be handed over", name)
+			}
+
+			f, err := fl.File()
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			files = append(files, f)
+			names = append(names, name)
+		}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer r.Close()
+
+	cmd := exec.Command(executable, os.Args[1:]...) //nolint:gosec
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, w)
+	cmd.Env = append(
+		upgradeEnv(),
+		envListenFDs+"="+strconv.Itoa(len(files)),
+		envListenFDNames+"="+strings.Join(names, ":"),
+		envListenPPID+"="+strconv.Itoa(os.Getpid()),
+		envUpgradeReadyFD+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The new process writes to the pipe once it is ready. Reading nothing
+	// means the pipe was closed because the new process exited.
+	ready := make(chan bool, 1)
+	go func() {
+		n, _ := io.ReadFull(r, make([]byte, 1))
+		ready <- n == 1
+	}()
+
+	select {
+	case ok := <-ready:
+		if !ok {
+			_ = cmd.Wait()
+			return microerror.Maskf(upgradeFailedError, "new process exited before being ready")
+		}
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return microerror.Maskf(upgradeFailedError, "new process not ready: %s", ctx.Err())
+	}
+
+	// Unix domain sockets are removed when their listener is closed, which
+	// would make them unreachable for the new process.
+	for _, ls := range listeners {
+		for _, l := range ls {
+			if u, ok := l.(*net.UnixListener); ok {
+				u.SetUnlinkOnClose(false)
+			}
+		}
+	}
+
+	return nil
+}
+
+// upgradeEnv returns the environment of the current process without the
+// variables of the handoff protocol. WATCHDOG_PID is removed as well, since it
+// names the current process, which would make the new process ignore the
+// watchdog once it became the main process of the service.
+func upgradeEnv() []string {
+	var env []string
+	for _, e := range os.Environ() {
+		k, _, _ := strings.Cut(e, "=")
+		switch k {
+		case envListenFDNames, envListenFDs, envListenPID, envListenPPID, envUpgradeReadyFD, envWatchdogPID:
+			continue
+		}
+		env = append(env, e)
+	}
+
+	return env
+}
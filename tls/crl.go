@@ -0,0 +1,186 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+const (
+	// crlCheckInterval is the minimum duration between two checks of the CRL
+	// files for changes.
+	crlCheckInterval = 10 * time.Second
+)
+
+// crlStore holds the parsed certificate revocation lists of the configured
+// files and reloads them once the files change.
+type crlStore struct {
+	// Dependencies.
+	logger micrologger.Logger
+
+	// Internals.
+	checked  time.Time
+	lists    []revocationList
+	modTimes map[string]time.Time
+	mutex    sync.Mutex
+
+	// Settings.
+	files    []string
+	interval time.Duration
+}
+
+type revocationList struct {
+	file    string
+	list    *x509.RevocationList
+	revoked map[string]struct{}
+}
+
+func newCRLStore(files []string, logger micrologger.Logger) (*crlStore, error) {
+	s := &crlStore{
+		logger: logger,
+
+		modTimes: map[string]time.Time{},
+
+		files:    files,
+		interval: crlCheckInterval,
+	}
+
+	err := s.reload()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return s, nil
+}
+
+// VerifyPeerCertificate implements tls.Config.VerifyPeerCertificate. It
+// rejects peers presenting a certificate which is revoked by any of the
+// configured CRLs. CRLs are only considered in case they are signed by the
+// issuer of the checked certificate.
+func (s *crlStore) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	lists := s.current()
+
+	for _, chain := range verifiedChains {
+		for i := 0; i < len(chain)-1; i++ {
+			cert, issuer := chain[i], chain[i+1]
+
+			for _, l := range lists {
+				if !bytes.Equal(l.list.RawIssuer, cert.RawIssuer) {
+					continue
+				}
+				if _, ok := l.revoked[cert.SerialNumber.String()]; !ok {
+					continue
+				}
+				if l.list.CheckSignatureFrom(issuer) != nil {
+					continue
+				}
+
+				revokedRejectionTotal.WithLabelValues(issuer.Subject.String()).Inc()
+
+				return microerror.Maskf(revokedCertificateError, "certificate %#q with serial %s is revoked by %#q", cert.Subject.String(), cert.SerialNumber.String(), l.file)
+			}
+		}
+	}
+
+	return nil
+}
+
+// current returns the currently loaded CRLs. The CRL files are checked for
+// changes at most once per interval and reloaded if necessary. In case the
+// reload fails the previously loaded CRLs are kept.
+func (s *crlStore) current() []revocationList {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if time.Since(s.checked) < s.interval {
+		return s.lists
+	}
+	s.checked = time.Now()
+
+	var changed bool
+	for _, f := range s.files {
+		fi, err := os.Stat(f)
+		if err != nil || !fi.ModTime().Equal(s.modTimes[f]) {
+			changed = true
+			break
+		}
+	}
+
+	if changed {
+		err := s.reloadLocked()
+		if err != nil && s.logger != nil {
+			s.logger.Log("level", "error", "message", "reloading certificate revocation lists failed", "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	return s.lists
+}
+
+func (s *crlStore) reload() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.checked = time.Now()
+
+	return s.reloadLocked()
+}
+
+func (s *crlStore) reloadLocked() error {
+	var lists []revocationList
+	modTimes := map[string]time.Time{}
+
+	for _, f := range s.files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		var ders [][]byte
+		if bytes.Contains(b, []byte("-----BEGIN")) {
+			for {
+				var block *pem.Block
+				block, b = pem.Decode(b)
+				if block == nil {
+					break
+				}
+				if block.Type == "X509 CRL" {
+					ders = append(ders, block.Bytes)
+				}
+			}
+		} else {
+			ders = append(ders, b)
+		}
+
+		for _, der := range ders {
+			list, err := x509.ParseRevocationList(der)
+			if err != nil {
+				return microerror.Maskf(invalidCertificateError, "parsing CRL %#q: %s", f, err.Error())
+			}
+
+			revoked := map[string]struct{}{}
+			for _, e := range list.RevokedCertificateEntries {
+				revoked[e.SerialNumber.String()] = struct{}{}
+			}
+
+			lists = append(lists, revocationList{file: f, list: list, revoked: revoked})
+		}
+
+		modTimes[f] = fi.ModTime()
+	}
+
+	s.lists = lists
+	s.modTimes = modTimes
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_CRLStore_VerifyPeerCertificate(t *testing.T) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	newClient := func(serial int64) *x509.Certificate {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "client"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &caKey.PublicKey, caKey)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		return cert
+	}
+
+	crlFile := filepath.Join(t.TempDir(), "crl.pem")
+	writeCRL := func(number int64, revoked ...int64) {
+		template := &x509.RevocationList{
+			Number:     big.NewInt(number),
+			ThisUpdate: now.Add(-time.Hour),
+			NextUpdate: now.Add(time.Hour),
+		}
+		for _, r := range revoked {
+			template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+				SerialNumber:   big.NewInt(r),
+				RevocationTime: now,
+			})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, template, ca, caKey)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		err = os.WriteFile(crlFile, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0600)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+		// Make sure the modification time changes, even on file systems with
+		// coarse timestamps.
+		mtime := now.Add(time.Duration(number) * time.Second)
+		err = os.Chtimes(crlFile, mtime, mtime)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	revokedClient := newClient(2)
+	validClient := newClient(3)
+
+	writeCRL(1, 2)
+
+	store, err := newCRLStore([]string{crlFile}, nil)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	err = store.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revokedClient, ca}})
+	if !IsRevokedCertificate(err) {
+		t.Fatal("expected", true, "got", false, "error", err)
+	}
+	err = store.VerifyPeerCertificate(nil, [][]*x509.Certificate{{validClient, ca}})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	// Revoke the other client certificate and make sure the changed CRL file is
+	// picked up.
+	writeCRL(2, 3)
+	store.interval = 0
+
+	err = store.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revokedClient, ca}})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	err = store.VerifyPeerCertificate(nil, [][]*x509.Certificate{{validClient, ca}})
+	if !IsRevokedCertificate(err) {
+		t.Fatal("expected", true, "got", false, "error", err)
+	}
+}
@@ -0,0 +1,204 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// devCertificateValidity is the validity of generated development
+	// certificates. The certificates are regenerated on every start, so they
+	// only have to outlive a single process.
+	devCertificateValidity = 7 * 24 * time.Hour
+)
+
+// DevCertificates holds an ephemeral CA and a server certificate issued by it.
+// It is only meant to be used for local development and testing.
+type DevCertificates struct {
+	CA   []byte // PEM encoded CA certificate.
+	Cert []byte // PEM encoded server certificate.
+	Key  []byte // PEM encoded server private key.
+}
+
+// GenerateDevCertificates creates an ephemeral CA and a server certificate
+// issued by it. The server certificate is valid for the given hosts as well as
+// localhost, 127.0.0.1 and ::1. Hosts may be DNS names or IP addresses.
+func GenerateDevCertificates(hosts []string) (DevCertificates, error) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	caSerial, err := newSerialNumber()
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          caSerial,
+		Subject:               pkix.Name{CommonName: "microkit development CA"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(devCertificateValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	serial, err := newSerialNumber()
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(devCertificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, h := range append([]string{"localhost", "127.0.0.1", "::1"}, hosts...) {
+		if h == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(h); ip != nil {
+			if !containsIP(template.IPAddresses, ip) {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			}
+		} else if !containsString(template.DNSNames, h) {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return DevCertificates{}, microerror.Mask(err)
+	}
+
+	c := DevCertificates{
+		CA:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+
+	return c, nil
+}
+
+// TLSConfig creates TLS configuration serving the generated server
+// certificate. The generated CA is configured as root CA.
+func (c DevCertificates) TLSConfig() (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair(c.Cert, c.Key)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(c.CA) {
+		return nil, microerror.Maskf(invalidCertificateError, "CA certificate must be PEM encoded")
+	}
+
+	tlsConfig := tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+	return &tlsConfig, nil
+}
+
+// WriteFiles writes the generated certificates to ca.crt, tls.crt and tls.key
+// within the given directory, which is created if it does not exist. The
+// returned CertFiles reference the written files, so that test clients can be
+// configured to trust the generated CA.
+func (c DevCertificates) WriteFiles(dir string) (CertFiles, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return CertFiles{}, microerror.Mask(err)
+	}
+
+	files := CertFiles{
+		RootCAs: []string{filepath.Join(dir, "ca.crt")},
+		Cert:    filepath.Join(dir, "tls.crt"),
+		Key:     filepath.Join(dir, "tls.key"),
+	}
+
+	err = os.WriteFile(files.RootCAs[0], c.CA, 0600)
+	if err != nil {
+		return CertFiles{}, microerror.Mask(err)
+	}
+	err = os.WriteFile(files.Cert, c.Cert, 0600)
+	if err != nil {
+		return CertFiles{}, microerror.Mask(err)
+	}
+	err = os.WriteFile(files.Key, c.Key, 0600)
+	if err != nil {
+		return CertFiles{}, microerror.Mask(err)
+	}
+
+	return files, nil
+}
+
+func containsIP(list []net.IP, ip net.IP) bool {
+	for _, l := range list {
+		if l.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return serial, nil
+}
@@ -0,0 +1,35 @@
+package tls
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+func Test_GenerateDevCertificates(t *testing.T) {
+	certs, err := GenerateDevCertificates([]string{"example.internal", "10.0.0.1"})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	files, err := certs.WriteFiles(t.TempDir())
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	tlsConfig, err := LoadTLSConfig(files)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	leaf := tlsConfig.Certificates[0].Leaf
+	for _, h := range []string{"localhost", "127.0.0.1", "::1", "example.internal", "10.0.0.1"} {
+		opts := x509.VerifyOptions{
+			DNSName: h,
+			Roots:   tlsConfig.RootCAs,
+		}
+		_, err := leaf.Verify(opts)
+		if err != nil {
+			t.Fatal("host", h, "expected", nil, "got", err)
+		}
+	}
+}
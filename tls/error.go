@@ -0,0 +1,59 @@
+package tls
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var expiredCertificateError = &microerror.Error{
+	Kind: "expiredCertificateError",
+}
+
+// IsExpiredCertificate asserts expiredCertificateError.
+func IsExpiredCertificate(err error) bool {
+	return microerror.Cause(err) == expiredCertificateError
+}
+
+var invalidCertificateError = &microerror.Error{
+	Kind: "invalidCertificateError",
+}
+
+// IsInvalidCertificate asserts invalidCertificateError.
+func IsInvalidCertificate(err error) bool {
+	return microerror.Cause(err) == invalidCertificateError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidKeyPairError = &microerror.Error{
+	Kind: "invalidKeyPairError",
+}
+
+// IsInvalidKeyPair asserts invalidKeyPairError.
+func IsInvalidKeyPair(err error) bool {
+	return microerror.Cause(err) == invalidKeyPairError
+}
+
+var invalidPassphraseError = &microerror.Error{
+	Kind: "invalidPassphraseError",
+}
+
+// IsInvalidPassphrase asserts invalidPassphraseError.
+func IsInvalidPassphrase(err error) bool {
+	return microerror.Cause(err) == invalidPassphraseError
+}
+
+var revokedCertificateError = &microerror.Error{
+	Kind: "revokedCertificateError",
+}
+
+// IsRevokedCertificate asserts revokedCertificateError.
+func IsRevokedCertificate(err error) bool {
+	return microerror.Cause(err) == revokedCertificateError
+}
@@ -0,0 +1,226 @@
+package tls
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des" //nolint:gosec
+	"crypto/pbkdf2"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"hash"
+	"os"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// Passphrase describes where the passphrase of encrypted private keys and
+// PKCS#12 bundles is read from. Only one of the fields is expected to be set.
+type Passphrase struct {
+	Env  string // Name of the environment variable holding the passphrase.
+	File string // Path of the file holding the passphrase.
+}
+
+// Read returns the configured passphrase. Trailing line breaks are removed
+// from passphrases read from files. An empty passphrase is returned in case
+// no source is configured.
+func (p Passphrase) Read() (string, error) {
+	if p.Env != "" && p.File != "" {
+		return "", microerror.Maskf(invalidPassphraseError, "passphrase must be read from either environment or file")
+	}
+
+	if p.Env != "" {
+		v, ok := os.LookupEnv(p.Env)
+		if !ok {
+			return "", microerror.Maskf(invalidPassphraseError, "environment variable %#q must be set", p.Env)
+		}
+
+		return v, nil
+	}
+
+	if p.File != "" {
+		b, err := os.ReadFile(p.File)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+
+	return "", nil
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptKeyPEM returns the given PEM encoded private key with all encrypted
+// PKCS#8 blocks being decrypted using the given passphrase. Blocks which are
+// not encrypted are returned as they are. The file is only used for error
+// messages.
+func decryptKeyPEM(file string, keyPEM []byte, passphrase string) ([]byte, error) {
+	var decrypted []byte
+
+	for {
+		var block *pem.Block
+		block, keyPEM = pem.Decode(keyPEM)
+		if block == nil {
+			break
+		}
+
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			if passphrase == "" {
+				return nil, microerror.Maskf(invalidPassphraseError, "key %#q is encrypted but no passphrase is configured", file)
+			}
+
+			der, err := decryptPKCS8(file, block.Bytes, passphrase)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		}
+
+		decrypted = append(decrypted, pem.EncodeToMemory(block)...)
+	}
+
+	return decrypted, nil
+}
+
+// decryptPKCS8 decrypts the given DER encoded PKCS#8 EncryptedPrivateKeyInfo
+// as defined by PBES2 in RFC 8018 and returns the DER encoded PKCS#8 private
+// key. The file is only used for error messages.
+func decryptPKCS8(file string, der []byte, passphrase string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	_, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: malformed encrypted private key: %s", file, err.Error())
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: unsupported encryption scheme %s, only PBES2 is supported", file, info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	_, err = asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params)
+	if err != nil {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: malformed PBES2 parameters: %s", file, err.Error())
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: unsupported key derivation function %s, only PBKDF2 is supported", file, params.KeyDerivationFunc.Algorithm)
+	}
+
+	var kdfParams pbkdf2Params
+	_, err = asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams)
+	if err != nil {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: malformed PBKDF2 parameters: %s", file, err.Error())
+	}
+
+	var newHash func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		newHash = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		newHash = sha256.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA384):
+		newHash = sha512.New384
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA512):
+		newHash = sha512.New
+	default:
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: unsupported PBKDF2 pseudorandom function %s", file, kdfParams.PRF.Algorithm)
+	}
+
+	var (
+		keyLength int
+		newCipher func(key []byte) (cipher.Block, error)
+	)
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength, newCipher = 16, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength, newCipher = 24, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength, newCipher = 32, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidDESEDE3CBC):
+		keyLength, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: unsupported encryption algorithm %s", file, params.EncryptionScheme.Algorithm)
+	}
+
+	var iv []byte
+	_, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv)
+	if err != nil {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: malformed encryption parameters: %s", file, err.Error())
+	}
+
+	key, err := pbkdf2.Key(newHash, passphrase, kdfParams.Salt, kdfParams.IterationCount, keyLength)
+	if err != nil {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: deriving key: %s", file, err.Error())
+	}
+
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: %s", file, err.Error())
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: initialization vector must be %d bytes", file, block.BlockSize())
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, microerror.Maskf(invalidKeyPairError, "decrypting key %#q: encrypted data must be a multiple of the block size", file)
+	}
+
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// A wrong passphrase results in garbage being decrypted, which is detected
+	// by either invalid padding or the decrypted key not being parsable.
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, microerror.Maskf(invalidPassphraseError, "decrypting key %#q: passphrase is likely wrong", file)
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, microerror.Maskf(invalidPassphraseError, "decrypting key %#q: passphrase is likely wrong", file)
+		}
+	}
+	decrypted = decrypted[:len(decrypted)-padding]
+
+	_, err = x509.ParsePKCS8PrivateKey(decrypted)
+	if err != nil {
+		return nil, microerror.Maskf(invalidPassphraseError, "decrypting key %#q: passphrase is likely wrong", file)
+	}
+
+	return decrypted, nil
+}
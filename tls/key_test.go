@@ -0,0 +1,196 @@
+package tls
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func Test_LoadTLSConfig_Encrypted(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		Bundle       bool
+		Passphrase   string
+		ErrorMatcher func(err error) bool
+	}{
+		// Case 1 ensures an encrypted PKCS#8 key is decrypted.
+		{
+			Bundle:       false,
+			Passphrase:   "secret",
+			ErrorMatcher: nil,
+		},
+		// Case 2 ensures a wrong passphrase is detected for PKCS#8 keys.
+		{
+			Bundle:       false,
+			Passphrase:   "wrong",
+			ErrorMatcher: IsInvalidPassphrase,
+		},
+		// Case 3 ensures a missing passphrase is detected for PKCS#8 keys.
+		{
+			Bundle:       false,
+			Passphrase:   "",
+			ErrorMatcher: IsInvalidPassphrase,
+		},
+		// Case 4 ensures a PKCS#12 bundle is decoded.
+		{
+			Bundle:       true,
+			Passphrase:   "secret",
+			ErrorMatcher: nil,
+		},
+		// Case 5 ensures a wrong passphrase is detected for PKCS#12 bundles.
+		{
+			Bundle:       true,
+			Passphrase:   "wrong",
+			ErrorMatcher: IsInvalidPassphrase,
+		},
+	}
+
+	for i, tc := range testCases {
+		dir := t.TempDir()
+		crtFile, keyFile := testWriteCertificate(t, dir, "test", now.Add(-time.Hour), now.Add(time.Hour), false)
+
+		passphraseFile := filepath.Join(dir, "passphrase")
+		err := os.WriteFile(passphraseFile, []byte(tc.Passphrase+"\n"), 0600)
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		files := CertFiles{
+			Passphrase: Passphrase{File: passphraseFile},
+		}
+		if tc.Bundle {
+			files.Cert = testWritePKCS12(t, dir, crtFile, keyFile, "secret")
+		} else {
+			files.Cert = crtFile
+			files.Key = testEncryptKey(t, dir, keyFile, "secret")
+		}
+
+		tlsConfig, err := LoadTLSConfig(files)
+		if (err != nil && tc.ErrorMatcher == nil) || (tc.ErrorMatcher != nil && !tc.ErrorMatcher(err)) {
+			t.Fatal("case", i+1, "expected", true, "got", false, "error", err)
+		}
+
+		if tc.ErrorMatcher == nil && tlsConfig.Certificates[0].PrivateKey == nil {
+			t.Fatal("case", i+1, "expected", "private key", "got", nil)
+		}
+	}
+}
+
+func testEncryptKey(t *testing.T, dir string, keyFile string, passphrase string) string {
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	block, _ := pem.Decode(b)
+
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	_, err = rand.Read(salt)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	_, err = rand.Read(iv)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, 2048, 32)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	padding := aes.BlockSize - len(block.Bytes)%aes.BlockSize
+	plain := append(block.Bytes, make([]byte, padding)...)
+	for i := len(block.Bytes); i < len(plain); i++ {
+		plain[i] = byte(padding)
+	}
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(c, iv).CryptBlocks(encrypted, plain)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: 2048,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	encryptedKeyFile := filepath.Join(dir, "key.encrypted.pem")
+	err = os.WriteFile(encryptedKeyFile, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	return encryptedKeyFile
+}
+
+func testWritePKCS12(t *testing.T, dir string, crtFile string, keyFile string, passphrase string) string {
+	b, err := os.ReadFile(crtFile)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	block, _ := pem.Decode(b)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	b, err = os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	block, _ = pem.Decode(b)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	pfx, err := pkcs12.Modern.Encode(key, cert, nil, passphrase)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	bundleFile := filepath.Join(dir, "bundle.p12")
+	err = os.WriteFile(bundleFile, pfx, 0600)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	return bundleFile
+}
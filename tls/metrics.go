@@ -0,0 +1,48 @@
+package tls
+
+import (
+	"errors"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	certificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tls_certificate_expiry_timestamp_seconds",
+			Help: "Unix timestamp at which a loaded TLS certificate expires.",
+		},
+		[]string{"file", "subject"},
+	)
+	revokedRejectionTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tls_revoked_certificate_rejections_total",
+			Help: "Number of times a peer certificate was rejected because it is revoked.",
+		},
+		[]string{"issuer"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(certificateExpiry)
+	prometheus.MustRegister(revokedRejectionTotal)
+}
+
+// RegisterMetrics registers the metrics of loaded TLS certificates with the given
+// registerer, in addition to the default registerer they are always registered
+// with. That way they are served next to the metrics of a server configuring
+// its own registry. Registering them multiple times with the same registerer
+// has no effect.
+func RegisterMetrics(r prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{certificateExpiry, revokedRejectionTotal} {
+		err := r.Register(c)
+		if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
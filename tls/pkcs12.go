@@ -0,0 +1,46 @@
+package tls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// IsPKCS12Bundle expresses whether the given file is treated as PKCS#12
+// bundle, which is the case for files having the .p12 or .pfx extension. A
+// PKCS#12 bundle holds the certificate, its chain and its key, which is why no
+// separate key file is needed.
+func IsPKCS12Bundle(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext == ".p12" || ext == ".pfx"
+}
+
+// decodePKCS12 decodes the given PKCS#12 bundle using the given passphrase and
+// returns the PEM encoded certificate chain and private key. The file is only
+// used for error messages.
+func decodePKCS12(file string, data []byte, passphrase string) ([]byte, []byte, error) {
+	key, cert, caCerts, err := pkcs12.DecodeChain(data, passphrase)
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) || errors.Is(err, pkcs12.ErrDecryption) {
+		return nil, nil, microerror.Maskf(invalidPassphraseError, "decrypting bundle %#q: passphrase is likely wrong", file)
+	} else if err != nil {
+		return nil, nil, microerror.Maskf(invalidKeyPairError, "decoding bundle %#q: %s", file, err.Error())
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, microerror.Maskf(invalidKeyPairError, "decoding bundle %#q: %s", file, err.Error())
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	for _, c := range caCerts {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
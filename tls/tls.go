@@ -6,78 +6,290 @@ import (
 	"encoding/pem"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
 )
 
 type CertFiles struct {
 	RootCAs []string // Root certificate authority file paths.
-	Cert    string   // X.509 certificate file path.
-	Key     string   // X.509 key file path.
+	Cert    string   // X.509 certificate or PKCS#12 bundle file path.
+	Key     string   // X.509 key file path, not needed for PKCS#12 bundles.
+
+	// ClientCAs are the certificate authority file paths used to verify client
+	// certificates. If given, clients are required to present a certificate
+	// issued by one of them.
+	ClientCAs []string
+	// CRLs are the certificate revocation list file paths used to reject
+	// revoked client certificates. The files may be PEM or DER encoded and are
+	// reloaded when they change. Using CRLs requires ClientCAs.
+	CRLs []string
+
+	// KeyPairs are additional X.509 certificate and key file paths served
+	// alongside Cert and Key. The certificate presented to a client is selected
+	// by the server name the client indicates via SNI. Cert and Key, or the
+	// first key pair if they are not given, act as the default certificate in
+	// case no certificate matches.
+	KeyPairs []KeyPair
+
+	// Passphrase is the source of the passphrase used to decrypt encrypted
+	// PKCS#8 private keys and PKCS#12 bundles, if any.
+	Passphrase Passphrase
+
+	// Logger is optional and used to emit warnings about loaded certificates,
+	// e.g. when the certificate chain does not verify against the configured
+	// root CAs.
+	Logger micrologger.Logger
+}
+
+// IsEmpty expresses whether no TLS settings are configured. The logger is not
+// considered a TLS setting.
+func (f CertFiles) IsEmpty() bool {
+	return len(f.RootCAs) == 0 && f.Cert == "" && f.Key == "" && len(f.ClientCAs) == 0 && len(f.CRLs) == 0 && len(f.KeyPairs) == 0 && f.Passphrase == (Passphrase{})
+}
+
+// KeyPair references the files of a single X.509 certificate and its key. In
+// case Cert references a PKCS#12 bundle, as decided by IsPKCS12Bundle, Key is
+// not needed.
+type KeyPair struct {
+	Cert string // X.509 certificate or PKCS#12 bundle file path.
+	Key  string // X.509 key file path, not needed for PKCS#12 bundles.
 }
 
 // LoadTLSConfig creates TLS configuration for given crtificate files. It
 // assumes X.509 keypair and sets minimum 1.2 minimum TLS version. All fields
 // of CertFiles are optional. If the field is missing, the corresponding
 // certificate will not be loaded.
+//
+// Private keys may be PKCS#8 encrypted and certificates may be given as
+// PKCS#12 bundles, both being decrypted using the configured passphrase.
+//
+// All loaded certificates are validated. Mismatching key pairs as well as
+// expired or not yet valid certificates are rejected. The expiry of every
+// loaded certificate is exported using the
+// tls_certificate_expiry_timestamp_seconds gauge.
 func LoadTLSConfig(files CertFiles) (*tls.Config, error) {
+	var keyPairs []KeyPair
+	if files.Cert != "" && (files.Key != "" || IsPKCS12Bundle(files.Cert)) {
+		keyPairs = append(keyPairs, KeyPair{Cert: files.Cert, Key: files.Key})
+	}
+	keyPairs = append(keyPairs, files.KeyPairs...)
+
 	var (
-		loadCert    = files.Cert != "" && files.Key != ""
-		loadRootCAs = len(files.RootCAs) > 0
+		loadCert      = len(keyPairs) > 0
+		loadRootCAs   = len(files.RootCAs) > 0
+		loadClientCAs = len(files.ClientCAs) > 0
 	)
 
-	if !loadCert && !loadRootCAs {
+	if !loadCert && !loadRootCAs && !loadClientCAs {
 		return nil, nil
 	}
+	if len(files.CRLs) > 0 && !loadClientCAs {
+		return nil, microerror.Maskf(invalidConfigError, "client CAs must not be empty when using CRLs")
+	}
+
+	now := time.Now()
 
-	var certificate tls.Certificate
+	var passphrase string
 	if loadCert {
-		cert, err := os.ReadFile(files.Cert)
+		var err error
+		passphrase, err = files.Passphrase.Read()
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
+	}
 
-		key, err := os.ReadFile(files.Key)
+	var certificates []tls.Certificate
+	for _, p := range keyPairs {
+		certificate, err := loadKeyPair(p, passphrase, now)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
-		certificate, err = tls.X509KeyPair(cert, key)
+		certificates = append(certificates, certificate)
+	}
+
+	var rootCAs *x509.CertPool
+	if loadRootCAs {
+		var err error
+		rootCAs, err = loadCertPool(files.RootCAs, now)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 	}
 
-	var rootCAs *x509.CertPool
 	if loadRootCAs {
-		rootCAs = x509.NewCertPool()
+		for i, c := range certificates {
+			verifyChain(files.Logger, keyPairs[i].Cert, c, rootCAs, now)
+		}
+	}
 
-		for _, caFile := range files.RootCAs {
-			caFile = filepath.Clean(caFile)
-			pemByte, err := os.ReadFile(caFile)
+	var clientCAs *x509.CertPool
+	if loadClientCAs {
+		var err error
+		clientCAs, err = loadCertPool(files.ClientCAs, now)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// In case no certificate is loaded we keep the empty certificate the TLS
+	// configuration always used to have.
+	if !loadCert {
+		certificates = []tls.Certificate{{}}
+	}
+
+	// The TLS server selects the first certificate supporting the server name
+	// indicated by the client. In case there is none, the first certificate is
+	// used. That way the first key pair acts as default.
+	tlsConfig := tls.Config{
+		Certificates: certificates,
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if loadClientCAs {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsConfig.ClientCAs = clientCAs
+	}
+
+	if len(files.CRLs) > 0 {
+		store, err := newCRLStore(files.CRLs, files.Logger)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		tlsConfig.VerifyPeerCertificate = store.VerifyPeerCertificate
+	}
+
+	return &tlsConfig, nil
+}
+
+// loadCertPool reads and validates all PEM encoded certificates of the given
+// files and adds them to a new certificate pool.
+func loadCertPool(caFiles []string, now time.Time) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	for _, caFile := range caFiles {
+		caFile = filepath.Clean(caFile)
+		pemByte, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for {
+			var block *pem.Block
+			block, pemByte = pem.Decode(pemByte)
+			if block == nil {
+				break
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
 			if err != nil {
 				return nil, microerror.Mask(err)
 			}
 
-			for {
-				var block *pem.Block
-				block, pemByte = pem.Decode(pemByte)
-				if block == nil {
-					break
-				}
-				cert, err := x509.ParseCertificate(block.Bytes)
-				if err != nil {
-					return nil, microerror.Mask(err)
-				}
-				rootCAs.AddCert(cert)
+			err = validateCertificate(caFile, cert, now)
+			if err != nil {
+				return nil, microerror.Mask(err)
 			}
+
+			pool.AddCert(cert)
 		}
 	}
 
-	tlsConfig := tls.Config{
-		Certificates: []tls.Certificate{certificate},
-		RootCAs:      rootCAs,
-		MinVersion:   tls.VersionTLS12,
+	return pool, nil
+}
+
+// loadKeyPair reads, decrypts and validates the certificate and key referenced
+// by the given key pair.
+func loadKeyPair(p KeyPair, passphrase string, now time.Time) (tls.Certificate, error) {
+	cert, err := os.ReadFile(p.Cert)
+	if err != nil {
+		return tls.Certificate{}, microerror.Mask(err)
+	}
+
+	var key []byte
+	if IsPKCS12Bundle(p.Cert) {
+		cert, key, err = decodePKCS12(p.Cert, cert, passphrase)
+		if err != nil {
+			return tls.Certificate{}, microerror.Mask(err)
+		}
+	} else {
+		key, err = os.ReadFile(p.Key)
+		if err != nil {
+			return tls.Certificate{}, microerror.Mask(err)
+		}
+
+		key, err = decryptKeyPEM(p.Key, key, passphrase)
+		if err != nil {
+			return tls.Certificate{}, microerror.Mask(err)
+		}
+	}
+
+	certificate, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return tls.Certificate{}, microerror.Maskf(invalidKeyPairError, "certificate %#q and key %#q: %s", p.Cert, p.Key, err.Error())
+	}
+	if certificate.Leaf == nil {
+		certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			return tls.Certificate{}, microerror.Mask(err)
+		}
+	}
+
+	err = validateCertificate(p.Cert, certificate.Leaf, now)
+	if err != nil {
+		return tls.Certificate{}, microerror.Mask(err)
+	}
+
+	return certificate, nil
+}
+
+// validateCertificate rejects certificates which are not valid at the given
+// point in time and tracks the expiry of valid certificates.
+func validateCertificate(file string, cert *x509.Certificate, now time.Time) error {
+	subject := cert.Subject.String()
+
+	if now.After(cert.NotAfter) {
+		return microerror.Maskf(expiredCertificateError, "certificate %#q in %#q expired at %s", subject, file, cert.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(cert.NotBefore) {
+		return microerror.Maskf(invalidCertificateError, "certificate %#q in %#q is not valid before %s", subject, file, cert.NotBefore.Format(time.RFC3339))
+	}
+
+	certificateExpiry.WithLabelValues(file, subject).Set(float64(cert.NotAfter.Unix()))
+
+	return nil
+}
+
+// verifyChain checks whether the given certificate chains up to the given root
+// CAs. A failing verification is not considered fatal, since the root CAs are
+// not necessarily the ones issuing the served certificate. We only warn about
+// it so misconfigurations can be spotted.
+func verifyChain(logger micrologger.Logger, file string, certificate tls.Certificate, rootCAs *x509.CertPool, now time.Time) {
+	if logger == nil {
+		return
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, b := range certificate.Certificate[1:] {
+		cert, err := x509.ParseCertificate(b)
+		if err != nil {
+			continue
+		}
+		intermediates.AddCert(cert)
+	}
+
+	opts := x509.VerifyOptions{
+		CurrentTime:   now,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		Roots:         rootCAs,
+	}
+
+	_, err := certificate.Leaf.Verify(opts)
+	if err != nil {
+		logger.Log("level", "warning", "message", "certificate chain does not verify against configured root CAs", "file", file, "error", err.Error())
 	}
-	return &tlsConfig, nil
 }
@@ -0,0 +1,256 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Test_CertFiles_IsEmpty ensures cert files are only empty in case none of
+// their TLS settings is configured.
+func Test_CertFiles_IsEmpty(t *testing.T) {
+	testCases := []struct {
+		Files    CertFiles
+		Expected bool
+	}{
+		// Case 1 ensures cert files without settings are empty.
+		{
+			Files:    CertFiles{},
+			Expected: true,
+		},
+		// Case 2 ensures cert files having a certificate are not empty.
+		{
+			Files:    CertFiles{Cert: "crt.pem", Key: "key.pem"},
+			Expected: false,
+		},
+		// Case 3 ensures cert files only having CRLs are not empty.
+		{
+			Files:    CertFiles{CRLs: []string{"crl.pem"}},
+			Expected: false,
+		},
+		// Case 4 ensures cert files only having a passphrase are not empty.
+		{
+			Files:    CertFiles{Passphrase: Passphrase{Env: "PASSPHRASE"}},
+			Expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		empty := tc.Files.IsEmpty()
+		if empty != tc.Expected {
+			t.Fatal("case", i+1, "expected", tc.Expected, "got", empty)
+		}
+	}
+}
+
+func Test_LoadTLSConfig(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		NotBefore    time.Time
+		NotAfter     time.Time
+		MismatchKey  bool
+		ErrorMatcher func(err error) bool
+	}{
+		// Case 1 ensures a valid certificate is loaded.
+		{
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(time.Hour),
+			MismatchKey:  false,
+			ErrorMatcher: nil,
+		},
+		// Case 2 ensures an expired certificate is rejected.
+		{
+			NotBefore:    now.Add(-2 * time.Hour),
+			NotAfter:     now.Add(-time.Hour),
+			MismatchKey:  false,
+			ErrorMatcher: IsExpiredCertificate,
+		},
+		// Case 3 ensures a certificate which is not yet valid is rejected.
+		{
+			NotBefore:    now.Add(time.Hour),
+			NotAfter:     now.Add(2 * time.Hour),
+			MismatchKey:  false,
+			ErrorMatcher: IsInvalidCertificate,
+		},
+		// Case 4 ensures a key not belonging to the certificate is rejected.
+		{
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(time.Hour),
+			MismatchKey:  true,
+			ErrorMatcher: IsInvalidKeyPair,
+		},
+	}
+
+	for i, tc := range testCases {
+		dir := t.TempDir()
+		crtFile, keyFile := testWriteCertificate(t, dir, "test", tc.NotBefore, tc.NotAfter, tc.MismatchKey)
+
+		tlsConfig, err := LoadTLSConfig(CertFiles{Cert: crtFile, Key: keyFile})
+		if (err != nil && tc.ErrorMatcher == nil) || (tc.ErrorMatcher != nil && !tc.ErrorMatcher(err)) {
+			t.Fatal("case", i+1, "expected", true, "got", false, "error", err)
+		}
+
+		if tc.ErrorMatcher == nil {
+			if len(tlsConfig.Certificates) != 1 {
+				t.Fatal("case", i+1, "expected", 1, "got", len(tlsConfig.Certificates))
+			}
+
+			g := certificateExpiry.WithLabelValues(crtFile, "CN=test")
+			if testutil.ToFloat64(g) != float64(tc.NotAfter.Unix()) {
+				t.Fatal("case", i+1, "expected", float64(tc.NotAfter.Unix()), "got", testutil.ToFloat64(g))
+			}
+		}
+	}
+}
+
+// Test_RegisterMetrics ensures the metrics of loaded TLS certificates are
+// gathered by the registry they are registered with.
+func Test_RegisterMetrics(t *testing.T) {
+	now := time.Now()
+
+	registry := prometheus.NewRegistry()
+
+	// Registering the metrics multiple times must not fail.
+	for i := 0; i < 2; i++ {
+		err := RegisterMetrics(registry)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	crtFile, keyFile := testWriteCertificate(t, t.TempDir(), "test", now.Add(-time.Hour), now.Add(time.Hour), false)
+
+	_, err := LoadTLSConfig(CertFiles{Cert: crtFile, Key: keyFile})
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	n, err := testutil.GatherAndCount(registry, "tls_certificate_expiry_timestamp_seconds")
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	if n == 0 {
+		t.Fatal("expected", "tls_certificate_expiry_timestamp_seconds", "got", n)
+	}
+}
+
+func Test_LoadTLSConfig_SNI(t *testing.T) {
+	now := time.Now()
+
+	defaultCrt, defaultKey := testWriteCertificate(t, t.TempDir(), "default.example", now.Add(-time.Hour), now.Add(time.Hour), false)
+	otherCrt, otherKey := testWriteCertificate(t, t.TempDir(), "other.example", now.Add(-time.Hour), now.Add(time.Hour), false)
+
+	files := CertFiles{
+		Cert: defaultCrt,
+		Key:  defaultKey,
+		KeyPairs: []KeyPair{
+			{Cert: otherCrt, Key: otherKey},
+		},
+	}
+
+	tlsConfig, err := LoadTLSConfig(files)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	testCases := []struct {
+		ServerName string
+		Expected   string
+	}{
+		{
+			ServerName: "default.example",
+			Expected:   "default.example",
+		},
+		{
+			ServerName: "other.example",
+			Expected:   "other.example",
+		},
+		{
+			ServerName: "unknown.example",
+			Expected:   "default.example",
+		},
+	}
+
+	for i, tc := range testCases {
+		serverConn, clientConn := net.Pipe()
+
+		go func() {
+			_ = tls.Server(serverConn, tlsConfig).Handshake()
+			serverConn.Close()
+		}()
+
+		//nolint:gosec
+		client := tls.Client(clientConn, &tls.Config{ServerName: tc.ServerName, InsecureSkipVerify: true})
+		err := client.Handshake()
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		subject := client.ConnectionState().PeerCertificates[0].Subject.CommonName
+		if subject != tc.Expected {
+			t.Fatal("case", i+1, "expected", tc.Expected, "got", subject)
+		}
+
+		client.Close()
+	}
+}
+
+func testWriteCertificate(t *testing.T, dir string, name string, notBefore, notAfter time.Time, mismatchKey bool) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	if mismatchKey {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal("expected", nil, "got", err)
+		}
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	crtFile := filepath.Join(dir, "crt.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	err = os.WriteFile(crtFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	return crtFile, keyFile
+}
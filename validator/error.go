@@ -0,0 +1,14 @@
+package validator
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidSchemaError = &microerror.Error{
+	Kind: "invalidSchemaError",
+}
+
+// IsInvalidSchema asserts invalidSchemaError.
+func IsInvalidSchema(err error) bool {
+	return microerror.Cause(err) == invalidSchemaError
+}
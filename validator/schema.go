@@ -0,0 +1,693 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/giantswarm/microerror"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Violation describes a single part of validated data not matching a schema.
+type Violation struct {
+	// Message describes why the data is invalid.
+	Message string
+	// Pointer is the JSON pointer of the invalid part of the data, e.g.
+	// /items/0/name. It is empty for the data itself.
+	Pointer string
+}
+
+// Error returns the description of the Violation to implement the error
+// interface.
+func (v Violation) Error() string {
+	if v.Pointer == "" {
+		return v.Message
+	}
+
+	return fmt.Sprintf("%s: %s", v.Pointer, v.Message)
+}
+
+// SchemaError indicates validated data does not match a schema. The server
+// responds with it using HTTP status 400 and server.CodeInvalidInput.
+type SchemaError struct {
+	violations []Violation
+}
+
+// Error returns the message of the SchemaError listing every violation to
+// implement the error interface.
+func (e SchemaError) Error() string {
+	var descriptions []string
+	for _, v := range e.violations {
+		descriptions = append(descriptions, v.Error())
+	}
+
+	return "invalid input: " + strings.Join(descriptions, ", ")
+}
+
+// Violations returns every violation found in the validated data.
+func (e SchemaError) Violations() []Violation {
+	return e.violations
+}
+
+// IsSchemaError asserts SchemaError.
+func IsSchemaError(err error) bool {
+	_, ok := microerror.Cause(err).(SchemaError)
+	return ok
+}
+
+// ToSchemaError asserts the given error to SchemaError and returns it.
+// ToSchemaError panics in case the underlying error is not of type
+// SchemaError. Therefore IsSchemaError should always be used to verify the
+// safe execution of ToSchemaError beforehand.
+func ToSchemaError(err error) SchemaError {
+	return microerror.Cause(err).(SchemaError)
+}
+
+// Schema validates data against a JSON Schema. It supports the keywords
+// describing types, required and additional properties, enums, constants,
+// formats, numeric and length limits, patterns, array items, the combination
+// of schemas via allOf, anyOf, oneOf and not, and references via $ref within
+// the loaded document. The OpenAPI keyword nullable is supported as well.
+// Unknown keywords and formats are ignored.
+type Schema struct {
+	patterns map[string]*regexp.Regexp
+	root     interface{}
+	schema   interface{}
+}
+
+// NewSchema loads the JSON Schema of the given JSON or YAML document.
+func NewSchema(document []byte) (*Schema, error) {
+	root, err := unmarshalSchemaDocument(document)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return newSchema(root, root)
+}
+
+// NewOpenAPISchema loads the JSON schema of the request body of the operation
+// of the given HTTP method and path within the given OpenAPI document, which
+// may be JSON or YAML.
+func NewOpenAPISchema(document []byte, method, path string) (*Schema, error) {
+	root, err := unmarshalSchemaDocument(document)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	pointer := "/paths/" + escapePointer(path) + "/" + strings.ToLower(method) + "/requestBody/content/" + escapePointer("application/json") + "/schema"
+	schema, ok := resolvePointer(root, pointer)
+	if !ok {
+		return nil, microerror.Maskf(invalidSchemaError, "operation %s %s has no JSON request body schema", strings.ToUpper(method), path)
+	}
+
+	return newSchema(root, schema)
+}
+
+func newSchema(root, schema interface{}) (*Schema, error) {
+	s := &Schema{
+		patterns: map[string]*regexp.Regexp{},
+		root:     root,
+		schema:   schema,
+	}
+
+	// Patterns are compiled upfront, so that invalid patterns cause loading
+	// the schema to fail instead of validating data.
+	err := s.compilePatterns(schema, map[string]bool{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return s, nil
+}
+
+// Validate validates the given data, e.g. a decoded request body of type
+// map[string]interface{}, against the schema. It returns a SchemaError listing
+// every violation in case the data does not match the schema.
+func (s *Schema) Validate(data interface{}) error {
+	data, err := normalizeData(data)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	violations := s.validate(s.schema, data, "", nil)
+	if len(violations) > 0 {
+		return microerror.Mask(SchemaError{violations: violations})
+	}
+
+	return nil
+}
+
+// validate validates the given data at the given pointer against the given
+// schema. The given refs are the references resolved for the data at the
+// pointer so far, which allows to detect references forming a cycle without
+// descending into the data, which would otherwise never end.
+func (s *Schema) validate(schema interface{}, data interface{}, pointer string, refs []string) []Violation {
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			return []Violation{{Message: "is not allowed", Pointer: pointer}}
+		}
+		return nil
+	case map[string]interface{}:
+		if ref, ok := schema["$ref"].(string); ok {
+			for _, r := range refs {
+				if r == ref {
+					return []Violation{{Message: fmt.Sprintf("references schema %#q cyclically", ref), Pointer: pointer}}
+				}
+			}
+			resolved, ok := s.resolveRef(ref)
+			if !ok {
+				return []Violation{{Message: fmt.Sprintf("references unknown schema %#q", ref), Pointer: pointer}}
+			}
+			return s.validate(resolved, data, pointer, append(refs[:len(refs):len(refs)], ref))
+		}
+
+		if data == nil && schema["nullable"] == true {
+			return nil
+		}
+
+		if t, ok := schema["type"]; ok && !matchesType(t, data) {
+			return []Violation{{Message: "must be of type " + typeNames(t), Pointer: pointer}}
+		}
+
+		var violations []Violation
+		violations = append(violations, s.validateValue(schema, data, pointer)...)
+		violations = append(violations, s.validateCombinations(schema, data, pointer, refs)...)
+
+		switch data := data.(type) {
+		case string:
+			violations = append(violations, s.validateString(schema, data, pointer)...)
+		case float64:
+			violations = append(violations, validateNumber(schema, data, pointer)...)
+		case []interface{}:
+			violations = append(violations, s.validateArray(schema, data, pointer)...)
+		case map[string]interface{}:
+			violations = append(violations, s.validateObject(schema, data, pointer)...)
+		}
+
+		return violations
+	default:
+		return nil
+	}
+}
+
+func (s *Schema) validateValue(schema map[string]interface{}, data interface{}, pointer string) []Violation {
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, data) {
+		return []Violation{{Message: fmt.Sprintf("must be %v", c), Pointer: pointer}}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		for _, e := range enum {
+			if reflect.DeepEqual(e, data) {
+				return nil
+			}
+		}
+
+		var values []string
+		for _, e := range enum {
+			values = append(values, fmt.Sprintf("%v", e))
+		}
+		return []Violation{{Message: "must be one of " + strings.Join(values, ", "), Pointer: pointer}}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateCombinations(schema map[string]interface{}, data interface{}, pointer string, refs []string) []Violation {
+	var violations []Violation
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			violations = append(violations, s.validate(sub, data, pointer, refs)...)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var matched bool
+		for _, sub := range anyOf {
+			if len(s.validate(sub, data, pointer, refs)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			violations = append(violations, Violation{Message: "must match at least one schema of anyOf", Pointer: pointer})
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		var matched int
+		for _, sub := range oneOf {
+			if len(s.validate(sub, data, pointer, refs)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			violations = append(violations, Violation{Message: "must match exactly one schema of oneOf", Pointer: pointer})
+		}
+	}
+
+	if not, ok := schema["not"]; ok {
+		if len(s.validate(not, data, pointer, refs)) == 0 {
+			violations = append(violations, Violation{Message: "must not match the schema of not", Pointer: pointer})
+		}
+	}
+
+	return violations
+}
+
+func (s *Schema) validateString(schema map[string]interface{}, data string, pointer string) []Violation {
+	var violations []Violation
+
+	length := float64(utf8.RuneCountInString(data))
+	if min, ok := schema["minLength"].(float64); ok && length < min {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must be at least %v characters long", min), Pointer: pointer})
+	}
+	if max, ok := schema["maxLength"].(float64); ok && length > max {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must be at most %v characters long", max), Pointer: pointer})
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok && !s.patterns[pattern].MatchString(data) {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must match the pattern %#q", pattern), Pointer: pointer})
+	}
+
+	if format, ok := schema["format"].(string); ok && !matchesFormat(format, data) {
+		violations = append(violations, Violation{Message: "must be a valid " + format, Pointer: pointer})
+	}
+
+	return violations
+}
+
+func validateNumber(schema map[string]interface{}, data float64, pointer string) []Violation {
+	var violations []Violation
+
+	// exclusiveMinimum and exclusiveMaximum are booleans modifying minimum
+	// and maximum in OpenAPI 3.0 and JSON Schema draft 4, while they are
+	// limits themselves in later drafts.
+	if min, ok := schema["minimum"].(float64); ok {
+		if schema["exclusiveMinimum"] == true && data <= min {
+			violations = append(violations, Violation{Message: fmt.Sprintf("must be greater than %v", min), Pointer: pointer})
+		} else if data < min {
+			violations = append(violations, Violation{Message: fmt.Sprintf("must be at least %v", min), Pointer: pointer})
+		}
+	}
+	if min, ok := schema["exclusiveMinimum"].(float64); ok && data <= min {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must be greater than %v", min), Pointer: pointer})
+	}
+	if max, ok := schema["maximum"].(float64); ok {
+		if schema["exclusiveMaximum"] == true && data >= max {
+			violations = append(violations, Violation{Message: fmt.Sprintf("must be less than %v", max), Pointer: pointer})
+		} else if data > max {
+			violations = append(violations, Violation{Message: fmt.Sprintf("must be at most %v", max), Pointer: pointer})
+		}
+	}
+	if max, ok := schema["exclusiveMaximum"].(float64); ok && data >= max {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must be less than %v", max), Pointer: pointer})
+	}
+
+	if multipleOf, ok := schema["multipleOf"].(float64); ok && multipleOf > 0 {
+		q := data / multipleOf
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			violations = append(violations, Violation{Message: fmt.Sprintf("must be a multiple of %v", multipleOf), Pointer: pointer})
+		}
+	}
+
+	if format, ok := schema["format"].(string); ok {
+		switch format {
+		case "int32":
+			if data != math.Trunc(data) || data < math.MinInt32 || data > math.MaxInt32 {
+				violations = append(violations, Violation{Message: "must be a valid int32", Pointer: pointer})
+			}
+		case "int64":
+			if data != math.Trunc(data) || data < math.MinInt64 || data > math.MaxInt64 {
+				violations = append(violations, Violation{Message: "must be a valid int64", Pointer: pointer})
+			}
+		}
+	}
+
+	return violations
+}
+
+func (s *Schema) validateArray(schema map[string]interface{}, data []interface{}, pointer string) []Violation {
+	var violations []Violation
+
+	length := float64(len(data))
+	if min, ok := schema["minItems"].(float64); ok && length < min {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must have at least %v items", min), Pointer: pointer})
+	}
+	if max, ok := schema["maxItems"].(float64); ok && length > max {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must have at most %v items", max), Pointer: pointer})
+	}
+
+	if schema["uniqueItems"] == true {
+	unique:
+		for i := range data {
+			for j := i + 1; j < len(data); j++ {
+				if reflect.DeepEqual(data[i], data[j]) {
+					violations = append(violations, Violation{Message: "must have unique items", Pointer: pointer})
+					break unique
+				}
+			}
+		}
+	}
+
+	if items, ok := schema["items"]; ok {
+		for i, item := range data {
+			violations = append(violations, s.validate(items, item, pointer+"/"+strconv.Itoa(i), nil)...)
+		}
+	}
+
+	return violations
+}
+
+func (s *Schema) validateObject(schema map[string]interface{}, data map[string]interface{}, pointer string) []Violation {
+	var violations []Violation
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, ok := r.(string)
+			if !ok {
+				continue
+			}
+			if _, ok := data[name]; !ok {
+				violations = append(violations, Violation{Message: "is required", Pointer: pointer + "/" + escapePointer(name)})
+			}
+		}
+	}
+
+	length := float64(len(data))
+	if min, ok := schema["minProperties"].(float64); ok && length < min {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must have at least %v properties", min), Pointer: pointer})
+	}
+	if max, ok := schema["maxProperties"].(float64); ok && length > max {
+		violations = append(violations, Violation{Message: fmt.Sprintf("must have at most %v properties", max), Pointer: pointer})
+	}
+
+	// Properties are validated in alphabetical order, so that violations are
+	// reported deterministically.
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+	for _, name := range names {
+		p := pointer + "/" + escapePointer(name)
+
+		if property, ok := properties[name]; ok {
+			violations = append(violations, s.validate(property, data[name], p, nil)...)
+		} else if hasAdditional {
+			if additional == false {
+				violations = append(violations, Violation{Message: "is not allowed", Pointer: p})
+			} else {
+				violations = append(violations, s.validate(additional, data[name], p, nil)...)
+			}
+		}
+	}
+
+	return violations
+}
+
+// compilePatterns compiles the patterns of the given schema and all of its
+// subschemas, including referenced ones. Only the keywords validate descends
+// into are walked, so that instance values like examples, defaults and enums
+// are not mistaken for schemas. The given refs are the references walked so
+// far, which prevents recursive schemas from being walked forever.
+func (s *Schema) compilePatterns(schema interface{}, refs map[string]bool) error {
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		if refs[ref] {
+			return nil
+		}
+		refs[ref] = true
+
+		// Unknown references are reported as violations when validating.
+		resolved, ok := s.resolveRef(ref)
+		if !ok {
+			return nil
+		}
+
+		return s.compilePatterns(resolved, refs)
+	}
+
+	if pattern, ok := m["pattern"].(string); ok {
+		if _, ok := s.patterns[pattern]; !ok {
+			r, err := regexp.Compile(pattern)
+			if err != nil {
+				return microerror.Maskf(invalidSchemaError, "pattern %#q is invalid: %s", pattern, err.Error())
+			}
+			s.patterns[pattern] = r
+		}
+	}
+
+	var subschemas []interface{}
+	for _, k := range []string{"additionalProperties", "items", "not"} {
+		if v, ok := m[k]; ok {
+			subschemas = append(subschemas, v)
+		}
+	}
+	for _, k := range []string{"allOf", "anyOf", "oneOf"} {
+		if v, ok := m[k].([]interface{}); ok {
+			subschemas = append(subschemas, v...)
+		}
+	}
+	if properties, ok := m["properties"].(map[string]interface{}); ok {
+		for _, v := range properties {
+			subschemas = append(subschemas, v)
+		}
+	}
+
+	for _, v := range subschemas {
+		err := s.compilePatterns(v, refs)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// resolveRef resolves the given reference within the loaded document. Only
+// references within the document like #/components/schemas/User are
+// supported.
+func (s *Schema) resolveRef(ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+
+	return resolvePointer(s.root, strings.TrimPrefix(ref, "#"))
+}
+
+func matchesType(t interface{}, data interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(t, data)
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok && matchesTypeName(name, data) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchesTypeName(name string, data interface{}) bool {
+	switch name {
+	case "array":
+		_, ok := data.([]interface{})
+		return ok
+	case "boolean":
+		_, ok := data.(bool)
+		return ok
+	case "integer":
+		n, ok := data.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return data == nil
+	case "number":
+		_, ok := data.(float64)
+		return ok
+	case "object":
+		_, ok := data.(map[string]interface{})
+		return ok
+	case "string":
+		_, ok := data.(string)
+		return ok
+	default:
+		return true
+	}
+}
+
+func matchesFormat(format string, data string) bool {
+	switch format {
+	case "byte":
+		_, err := base64.StdEncoding.DecodeString(data)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, data)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, data)
+		return err == nil
+	case "email":
+		a, err := mail.ParseAddress(data)
+		return err == nil && a.Address == data
+	case "hostname":
+		return len(data) <= 253 && hostnamePattern.MatchString(data)
+	case "ipv4":
+		ip := net.ParseIP(data)
+		return ip != nil && ip.To4() != nil && !strings.Contains(data, ":")
+	case "ipv6":
+		ip := net.ParseIP(data)
+		return ip != nil && strings.Contains(data, ":")
+	case "uri":
+		u, err := url.Parse(data)
+		return err == nil && u.IsAbs()
+	case "uuid":
+		return uuidPattern.MatchString(data)
+	default:
+		return true
+	}
+}
+
+func typeNames(t interface{}) string {
+	if names, ok := t.([]interface{}); ok {
+		var s []string
+		for _, n := range names {
+			s = append(s, fmt.Sprintf("%v", n))
+		}
+		return strings.Join(s, " or ")
+	}
+
+	return fmt.Sprintf("%v", t)
+}
+
+// normalizeData converts the given data into the types produced by decoding
+// JSON into interface{}, so that e.g. structs and integers can be validated.
+func normalizeData(data interface{}) (interface{}, error) {
+	switch data.(type) {
+	case nil, bool, float64, string, []interface{}, map[string]interface{}:
+		if isNormalized(data) {
+			return data, nil
+		}
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var normalized interface{}
+	err = json.Unmarshal(b, &normalized)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return normalized, nil
+}
+
+func isNormalized(data interface{}) bool {
+	switch data := data.(type) {
+	case nil, bool, float64, string:
+		return true
+	case []interface{}:
+		for _, v := range data {
+			if !isNormalized(v) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		for _, v := range data {
+			if !isNormalized(v) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// unmarshalSchemaDocument decodes the given JSON or YAML document into the
+// types produced by decoding JSON into interface{}.
+func unmarshalSchemaDocument(document []byte) (interface{}, error) {
+	var root interface{}
+	err := yaml.Unmarshal(document, &root)
+	if err != nil {
+		return nil, microerror.Maskf(invalidSchemaError, "%s", err.Error())
+	}
+
+	root, err = normalizeData(root)
+	if err != nil {
+		return nil, microerror.Maskf(invalidSchemaError, "%s", err.Error())
+	}
+
+	return root, nil
+}
+
+// resolvePointer returns the value of the given JSON pointer within the given
+// document.
+func resolvePointer(document interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return document, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	current := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch c := current.(type) {
+		case map[string]interface{}:
+			v, ok := c[token]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			current = c[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// escapePointer escapes the given token to be used within JSON pointers.
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
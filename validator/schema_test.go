@@ -0,0 +1,351 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["name", "kind"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "minLength": 3, "pattern": "^[a-z-]+$"},
+    "kind": {"type": "string", "enum": ["cluster", "node"]},
+    "replicas": {"type": "integer", "minimum": 1, "maximum": 10},
+    "email": {"type": "string", "format": "email"},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "nodes": {"type": "array", "maxItems": 2, "items": {"$ref": "#/definitions/node"}}
+  },
+  "definitions": {
+    "node": {
+      "type": "object",
+      "required": ["id"],
+      "properties": {"id": {"type": "string", "format": "uuid"}}
+    }
+  }
+}`
+
+const testOpenAPIDocument = `
+openapi: 3.0.3
+paths:
+  /v1/users/{id}/:
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+components:
+  schemas:
+    User:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        age:
+          type: integer
+          minimum: 0
+          exclusiveMinimum: true
+        nickname:
+          type: string
+          nullable: true
+`
+
+func Test_Schema_Validate(t *testing.T) {
+	testCases := []struct {
+		Data               interface{}
+		ErrorMatcher       func(err error) bool
+		ExpectedViolations []Violation
+	}{
+		// Case 1 ensures valid data is accepted.
+		{
+			Data: map[string]interface{}{
+				"name":     "foo-bar",
+				"kind":     "node",
+				"replicas": 3.0,
+				"email":    "foo@example.com",
+				"labels":   map[string]interface{}{"team": "foo"},
+				"nodes":    []interface{}{map[string]interface{}{"id": "8ba7b7a1-7b58-4c34-9d2c-0e3e2b0d3f8a"}},
+			},
+			ErrorMatcher:       nil,
+			ExpectedViolations: nil,
+		},
+		// Case 2 ensures missing required properties are reported.
+		{
+			Data:         map[string]interface{}{},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "is required", Pointer: "/name"},
+				{Message: "is required", Pointer: "/kind"},
+			},
+		},
+		// Case 3 ensures every violation is reported with its JSON pointer.
+		{
+			Data: map[string]interface{}{
+				"name":     "Fo",
+				"kind":     "pod",
+				"replicas": 1.5,
+				"email":    "foo",
+				"unknown":  true,
+			},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "must be a valid email", Pointer: "/email"},
+				{Message: "must be one of cluster, node", Pointer: "/kind"},
+				{Message: "must be at least 3 characters long", Pointer: "/name"},
+				{Message: "must match the pattern `^[a-z-]+$`", Pointer: "/name"},
+				{Message: "must be of type integer", Pointer: "/replicas"},
+				{Message: "is not allowed", Pointer: "/unknown"},
+			},
+		},
+		// Case 4 ensures nested data and references are validated.
+		{
+			Data: map[string]interface{}{
+				"name":   "foo",
+				"kind":   "cluster",
+				"labels": map[string]interface{}{"a/b": 1.0},
+				"nodes": []interface{}{
+					map[string]interface{}{"id": "foo"},
+					map[string]interface{}{},
+					map[string]interface{}{"id": "8ba7b7a1-7b58-4c34-9d2c-0e3e2b0d3f8a"},
+				},
+			},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "must be of type string", Pointer: "/labels/a~1b"},
+				{Message: "must have at most 2 items", Pointer: "/nodes"},
+				{Message: "must be a valid uuid", Pointer: "/nodes/0/id"},
+				{Message: "is required", Pointer: "/nodes/1/id"},
+			},
+		},
+		// Case 5 ensures numeric limits are validated.
+		{
+			Data:         map[string]interface{}{"name": "foo", "kind": "node", "replicas": 11.0},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "must be at most 10", Pointer: "/replicas"},
+			},
+		},
+		// Case 6 ensures data other than decoded JSON is validated.
+		{
+			Data: struct {
+				Kind     string `json:"kind"`
+				Name     string `json:"name"`
+				Replicas int    `json:"replicas"`
+			}{
+				Kind:     "node",
+				Name:     "foo",
+				Replicas: 0,
+			},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "must be at least 1", Pointer: "/replicas"},
+			},
+		},
+		// Case 7 ensures the type of the data itself is validated.
+		{
+			Data:         "foo",
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "must be of type object", Pointer: ""},
+			},
+		},
+	}
+
+	schema, err := NewSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal("expected", nil, "got", err)
+	}
+
+	for i, tc := range testCases {
+		err := schema.Validate(tc.Data)
+		if tc.ErrorMatcher == nil {
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			continue
+		}
+		if !tc.ErrorMatcher(err) {
+			t.Fatal("case", i+1, "expected", true, "got", false)
+		}
+
+		violations := ToSchemaError(err).Violations()
+		if !reflect.DeepEqual(tc.ExpectedViolations, violations) {
+			t.Fatal("case", i+1, "expected", tc.ExpectedViolations, "got", violations)
+		}
+	}
+}
+
+func Test_Schema_NewOpenAPISchema(t *testing.T) {
+	testCases := []struct {
+		Method             string
+		Path               string
+		Data               interface{}
+		ErrorMatcher       func(err error) bool
+		ExpectedViolations []Violation
+	}{
+		// Case 1 ensures valid data is accepted.
+		{
+			Method:             "PUT",
+			Path:               "/v1/users/{id}/",
+			Data:               map[string]interface{}{"name": "foo", "age": 1.0, "nickname": nil},
+			ErrorMatcher:       nil,
+			ExpectedViolations: nil,
+		},
+		// Case 2 ensures the referenced component schema is validated.
+		{
+			Method:       "put",
+			Path:         "/v1/users/{id}/",
+			Data:         map[string]interface{}{"age": 0.0, "nickname": 1.0},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "is required", Pointer: "/name"},
+				{Message: "must be greater than 0", Pointer: "/age"},
+				{Message: "must be of type string", Pointer: "/nickname"},
+			},
+		},
+		// Case 3 ensures loading unknown operations fails.
+		{
+			Method:             "POST",
+			Path:               "/v1/users/{id}/",
+			Data:               nil,
+			ErrorMatcher:       IsInvalidSchema,
+			ExpectedViolations: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		schema, err := NewOpenAPISchema([]byte(testOpenAPIDocument), tc.Method, tc.Path)
+		if err == nil {
+			err = schema.Validate(tc.Data)
+		}
+		if tc.ErrorMatcher == nil {
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			continue
+		}
+		if !tc.ErrorMatcher(err) {
+			t.Fatal("case", i+1, "expected", true, "got", false)
+		}
+		if tc.ExpectedViolations == nil {
+			continue
+		}
+
+		violations := ToSchemaError(err).Violations()
+		if !reflect.DeepEqual(tc.ExpectedViolations, violations) {
+			t.Fatal("case", i+1, "expected", tc.ExpectedViolations, "got", violations)
+		}
+	}
+}
+
+func Test_Schema_Pattern(t *testing.T) {
+	testCases := []struct {
+		Schema       string
+		ErrorMatcher func(err error) bool
+	}{
+		// Case 1 ensures invalid patterns cause loading the schema to fail.
+		{
+			Schema:       `{"properties": {"name": {"pattern": "("}}}`,
+			ErrorMatcher: IsInvalidSchema,
+		},
+		// Case 2 ensures invalid patterns of referenced schemas cause loading
+		// the schema to fail.
+		{
+			Schema:       `{"items": {"$ref": "#/$defs/name"}, "$defs": {"name": {"allOf": [{"pattern": "("}]}}}`,
+			ErrorMatcher: IsInvalidSchema,
+		},
+		// Case 3 ensures instance values named pattern are not compiled.
+		{
+			Schema:       `{"properties": {"a": {"type": "string", "example": {"pattern": "("}, "enum": [{"pattern": "("}]}}}`,
+			ErrorMatcher: nil,
+		},
+		// Case 4 ensures recursive schemas are loaded.
+		{
+			Schema:       `{"$ref": "#/$defs/node", "$defs": {"node": {"properties": {"name": {"pattern": "^[a-z]+$"}, "children": {"items": {"$ref": "#/$defs/node"}}}}}}`,
+			ErrorMatcher: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		_, err := NewSchema([]byte(tc.Schema))
+		if tc.ErrorMatcher == nil {
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			continue
+		}
+		if !tc.ErrorMatcher(err) {
+			t.Fatal("case", i+1, "expected", true, "got", false)
+		}
+	}
+}
+
+func Test_Schema_Ref(t *testing.T) {
+	testCases := []struct {
+		Schema             string
+		Data               interface{}
+		ErrorMatcher       func(err error) bool
+		ExpectedViolations []Violation
+	}{
+		// Case 1 ensures recursive schemas descending into the data are
+		// validated.
+		{
+			Schema: `{"$ref":"#/$defs/node","$defs":{"node":{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#/$defs/node"}}}}}}`,
+			Data: map[string]interface{}{
+				"children": []interface{}{
+					map[string]interface{}{"children": []interface{}{}},
+					map[string]interface{}{"children": "foo"},
+				},
+			},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "must be of type array", Pointer: "/children/1/children"},
+			},
+		},
+		// Case 2 ensures references forming a cycle without descending into
+		// the data are reported instead of being resolved forever.
+		{
+			Schema:       `{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#/$defs/a"}},"$ref":"#/$defs/a"}`,
+			Data:         map[string]interface{}{},
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "references schema `#/$defs/a` cyclically", Pointer: ""},
+			},
+		},
+		// Case 3 ensures cycles via combined schemas are reported as well.
+		{
+			Schema:       `{"$defs":{"a":{"allOf":[{"$ref":"#/$defs/a"}]}},"$ref":"#/$defs/a"}`,
+			Data:         "foo",
+			ErrorMatcher: IsSchemaError,
+			ExpectedViolations: []Violation{
+				{Message: "references schema `#/$defs/a` cyclically", Pointer: ""},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		schema, err := NewSchema([]byte(tc.Schema))
+		if err != nil {
+			t.Fatal("case", i+1, "expected", nil, "got", err)
+		}
+
+		err = schema.Validate(tc.Data)
+		if tc.ErrorMatcher == nil {
+			if err != nil {
+				t.Fatal("case", i+1, "expected", nil, "got", err)
+			}
+			continue
+		}
+		if !tc.ErrorMatcher(err) {
+			t.Fatal("case", i+1, "expected", true, "got", false)
+		}
+
+		violations := ToSchemaError(err).Violations()
+		if !reflect.DeepEqual(tc.ExpectedViolations, violations) {
+			t.Fatal("case", i+1, "expected", tc.ExpectedViolations, "got", violations)
+		}
+	}
+}